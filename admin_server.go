@@ -0,0 +1,331 @@
+package ark
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	hz "github.com/cloudwego/hertz/pkg/app"
+	hzsrv "github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/samber/lo"
+
+	"github.com/arklib/ark/errx"
+	"github.com/arklib/ark/http/middleware"
+	"github.com/arklib/ark/http/result"
+	"github.com/arklib/ark/logger"
+	"github.com/arklib/ark/queue"
+	"github.com/arklib/ark/util"
+)
+
+const adminMaskValue = "******"
+
+var ErrAdminAuthFailed = errx.New("admin auth failed", 401)
+
+type (
+	AdminHttpRoute struct {
+		Method          string   `json:"method"`
+		Path            string   `json:"path"`
+		Title           string   `json:"title,omitempty"`
+		Handler         string   `json:"handler"`
+		HttpMiddlewares []string `json:"httpMiddlewares"`
+		ApiMiddlewares  []string `json:"apiMiddlewares"`
+	}
+
+	AdminRPCRoute struct {
+		Path           string   `json:"path"`
+		Title          string   `json:"title,omitempty"`
+		Handler        string   `json:"handler"`
+		ApiMiddlewares []string `json:"apiMiddlewares"`
+	}
+
+	adminServer struct {
+		srv    *Server
+		hzSrv  *hzsrv.Hertz
+		queues []any
+		// queue tasks run by runTask, cancelled on shutdown
+		ctx     context.Context
+		cancel  context.CancelFunc
+		mu      sync.Mutex
+		running map[string]context.CancelFunc
+		wg      sync.WaitGroup
+	}
+)
+
+func newAdminServer(srv *Server) *adminServer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &adminServer{
+		srv:     srv,
+		ctx:     ctx,
+		cancel:  cancel,
+		running: make(map[string]context.CancelFunc),
+	}
+}
+
+func (s *adminServer) init() error {
+	srv := s.srv
+	srv.Logger.Debug("[ark] init admin server")
+
+	config := srv.config.AdminServer
+	if config.Token == "" {
+		return errx.New("admin server token cannot be empty")
+	}
+
+	hzSrv := hzsrv.New(hzsrv.WithHostPorts(config.Addr))
+	hzSrv.Use(middleware.Recovery(), s.auth(config.Token))
+
+	router := hzSrv.Group("/admin")
+	router.GET("/routes", s.routes)
+	router.GET("/config", s.config)
+	router.PUT("/logger/level", s.setLogLevel)
+	router.GET("/tasks", s.tasks)
+	router.POST("/tasks/run", s.runTask)
+
+	s.hzSrv = hzSrv
+	return nil
+}
+
+func (s *adminServer) run() error {
+	if s.hzSrv == nil {
+		if err := s.init(); err != nil {
+			return err
+		}
+	}
+	return s.hzSrv.Run()
+}
+
+func (s *adminServer) shutdown(ctx context.Context) (err error) {
+	if s.hzSrv != nil && s.hzSrv.IsRunning() {
+		err = s.hzSrv.Shutdown(ctx)
+	}
+
+	// stop running queue tasks
+	s.cancel()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = errors.Join(err, ctx.Err())
+	}
+	return
+}
+
+// AddQueues add queues (pointer to struct of queues) for task list
+func (s *adminServer) AddQueues(queues any) {
+	s.queues = append(s.queues, queues)
+}
+
+// auth token lookup: "Authorization: Bearer {token}", not query, urls are logged
+func (s *adminServer) auth(token string) hz.HandlerFunc {
+	return func(ctx context.Context, c *hz.RequestContext) {
+		value, _ := strings.CutPrefix(string(c.GetHeader("Authorization")), "Bearer ")
+
+		if subtle.ConstantTimeCompare([]byte(value), []byte(token)) != 1 {
+			result.Error(c, ErrAdminAuthFailed)
+			return
+		}
+		c.Next(ctx)
+	}
+}
+
+func (s *adminServer) routes(ctx context.Context, c *hz.RequestContext) {
+	srv := s.srv
+
+	httpRoutes := make([]*AdminHttpRoute, 0)
+	if srv.HttpServer != nil {
+		for _, route := range srv.HttpServer.GetRoutes() {
+			httpRoutes = append(httpRoutes, &AdminHttpRoute{
+				Method:          route.Method,
+				Path:            route.FullPath,
+				Title:           route.Title,
+				Handler:         route.Handler.Name,
+				HttpMiddlewares: lo.Map(route.HttpMiddlewares, adminFnName[HttpMiddleware]),
+				ApiMiddlewares:  lo.Map(route.ApiMiddlewares, adminFnName[ApiMiddleware]),
+			})
+		}
+	}
+
+	rpcRoutes := make([]*AdminRPCRoute, 0)
+	if srv.RPCServer != nil {
+		for _, route := range srv.RPCServer.GetRoutes() {
+			rpcRoutes = append(rpcRoutes, &AdminRPCRoute{
+				Path:           route.FullPath,
+				Title:          route.Title,
+				Handler:        route.Handler.Name,
+				ApiMiddlewares: lo.Map(route.ApiMiddlewares, adminFnName[ApiMiddleware]),
+			})
+		}
+	}
+
+	result.Success(c, map[string]any{
+		"http": httpRoutes,
+		"rpc":  rpcRoutes,
+	})
+}
+
+func (s *adminServer) config(ctx context.Context, c *hz.RequestContext) {
+	result.Success(c, adminMask(reflect.ValueOf(s.srv.config)))
+}
+
+func (s *adminServer) setLogLevel(ctx context.Context, c *hz.RequestContext) {
+	in := new(struct {
+		Level string `json:"level"`
+	})
+	if err := c.Bind(in); err != nil {
+		result.Error(c, errx.New("input error", 400, err))
+		return
+	}
+
+	if !lo.Contains(logger.Levels, in.Level) {
+		result.Error(c, errx.Sprintf("unknown logger level: %s", in.Level).WithCode(400))
+		return
+	}
+
+	s.srv.Logger.SetLevel(logger.GetLogLevel(in.Level))
+	s.srv.Logger.Infof("[admin.server] logger level: %s", in.Level)
+	result.Success(c, in)
+}
+
+func (s *adminServer) tasks(ctx context.Context, c *hz.RequestContext) {
+	queueTasks := make([]string, 0)
+	for _, queues := range s.queues {
+		for _, cmdTask := range queue.GetTasks(queues) {
+			queueTasks = append(queueTasks, cmdTask.Name)
+		}
+	}
+
+	s.mu.Lock()
+	running := lo.Keys(s.running)
+	s.mu.Unlock()
+	slices.Sort(running)
+
+	result.Success(c, map[string]any{
+		"tasks":   s.srv.Task.Names(),
+		"queues":  queueTasks,
+		"running": running,
+	})
+}
+
+// runTask action: run | retry, queue task runs the consumer in background until shutdown (once per name),
+// retry pushes due messages once
+func (s *adminServer) runTask(ctx context.Context, c *hz.RequestContext) {
+	in := new(struct {
+		Name   string `json:"name"`
+		Action string `json:"action"`
+	})
+	if err := c.Bind(in); err != nil {
+		result.Error(c, errx.New("input error", 400, err))
+		return
+	}
+	if in.Name == "" {
+		result.Error(c, errx.New("task name cannot be empty", 400))
+		return
+	}
+	if in.Action == "" {
+		in.Action = "run"
+	}
+
+	s.srv.Logger.Infof("[admin.server] %s task: %s", in.Action, in.Name)
+	if err := s.execTask(in.Name, in.Action); err != nil {
+		result.Error(c, errx.New(err))
+		return
+	}
+	result.Success(c, in)
+}
+
+func (s *adminServer) execTask(name, action string) error {
+	for _, queues := range s.queues {
+		for _, cmdTask := range queue.GetTasks(queues, name) {
+			switch action {
+			case "run":
+				return s.runQueueTask(cmdTask)
+			case "retry":
+				return cmdTask.Retry()
+			default:
+				return errx.Sprintf("unknown task action: %s", action).WithCode(400)
+			}
+		}
+	}
+
+	if action != "run" {
+		return errx.Sprintf("unknown task action: %s", action).WithCode(400)
+	}
+	return s.srv.Task.Exec(name)
+}
+
+// runQueueTask run the consumer in background, a running task is rejected
+func (s *adminServer) runQueueTask(cmdTask *queue.CmdTask) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.running[cmdTask.Name]; ok {
+		return errx.Sprintf("task is running: %s", cmdTask.Name).WithCode(409)
+	}
+	if s.ctx.Err() != nil {
+		return errx.New("admin server is shutting down", 503)
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.running[cmdTask.Name] = cancel
+	s.wg.Add(1)
+	go func() {
+		defer func() {
+			cancel()
+			s.mu.Lock()
+			delete(s.running, cmdTask.Name)
+			s.mu.Unlock()
+			s.wg.Done()
+		}()
+
+		if err := cmdTask.RunContext(ctx); err != nil {
+			s.srv.Logger.Errorf("[admin.server] task: %s, error: %s", cmdTask.Name, err)
+		}
+	}()
+	return nil
+}
+
+func adminFnName[Fn any](fn Fn, _ int) string {
+	return util.GetFnFullName(fn)
+}
+
+// adminMask config to map, non-empty `secret:"true"` fields are masked
+func adminMask(v reflect.Value) any {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		data := make(map[string]any)
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if field.Tag.Get("secret") == "true" && !v.Field(i).IsZero() {
+				data[field.Name] = adminMaskValue
+				continue
+			}
+			data[field.Name] = adminMask(v.Field(i))
+		}
+		return data
+	case reflect.Slice, reflect.Array:
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = adminMask(v.Index(i))
+		}
+		return items
+	default:
+		return v.Interface()
+	}
+}
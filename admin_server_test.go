@@ -0,0 +1,48 @@
+package ark
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/arklib/ark/queue"
+	"github.com/arklib/ark/queue/driver"
+	"github.com/arklib/ark/queue/retry"
+)
+
+func TestAdminRunQueueTask(t *testing.T) {
+	queues := &struct {
+		Test *queue.Queue[struct{}]
+	}{
+		Test: queue.Define[struct{}](queue.Config{
+			Name:        "test",
+			Driver:      driver.NewMemoryDriver(),
+			RetryDriver: retry.NewMemoryRetryDriver(retry.MemoryRetryConfig{}),
+		}),
+	}
+	queues.Test.AddTask("handle", func(ctx context.Context, data *struct{}) error {
+		return nil
+	}, queue.TaskConfig{})
+
+	s := newAdminServer(&Server{})
+	s.AddQueues(queues)
+
+	if err := s.execTask("test:handle", "run"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.execTask("test:handle", "run"); err == nil {
+		t.Fatal("run a running task: want error, got nil")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %s", err)
+	}
+	if len(s.running) != 0 {
+		t.Fatalf("running tasks after shutdown: %v", s.running)
+	}
+	if err := s.execTask("test:handle", "run"); err == nil {
+		t.Fatal("run after shutdown: want error, got nil")
+	}
+}
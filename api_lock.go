@@ -0,0 +1,171 @@
+package ark
+
+import (
+	"errors"
+	"log"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/arklib/ark/errx"
+	"github.com/arklib/ark/lock"
+	"github.com/arklib/ark/util"
+)
+
+const lockTagName = "lock"
+
+var ErrApiLockKeyEmpty = errx.New("lock key is empty", 400)
+
+type (
+	ApiLockConfig struct {
+		Lock *lock.Lock
+		// key from ApiPayload.In, default ApiLockKey("")
+		Key func(in any) (any, error)
+		// error code on contention, default 409
+		Code int
+		// error message on contention, default "resource is locked"
+		Message string
+	}
+
+	apiLockFieldsKey struct {
+		rType reflect.Type
+		name  string
+	}
+
+	apiLockFieldIndexes struct {
+		indexes [][]int
+		err     error
+	}
+)
+
+// apiLockFields field index paths of lock tags by input type and tag value
+var apiLockFields sync.Map
+
+// ApiLock lock before next, unlock after, for http and rpc routes
+func ApiLock(c ApiLockConfig) ApiMiddleware {
+	if c.Lock == nil {
+		log.Fatal("[api.lock] Lock is required.")
+	}
+	if c.Key == nil {
+		c.Key = ApiLockKey("")
+	}
+	if c.Code == 0 {
+		c.Code = 409
+	}
+	if c.Message == "" {
+		c.Message = "resource is locked"
+	}
+
+	return func(p *ApiPayload) error {
+		key, err := c.Key(p.In)
+		if err != nil {
+			return err
+		}
+
+		payload, err := c.Lock.Lock(p.Ctx, key)
+		if errors.Is(err, lock.ErrIsLocked) {
+			return errx.New(c.Message, c.Code, err)
+		}
+		if err != nil {
+			return err
+		}
+		defer func() {
+			// ErrNotOwner: lock expired before the handler returned
+			if err := payload.Unlock(); err != nil {
+				p.Ctx.srv.Logger.CtxWarnf(p.Ctx, "[api.lock] path: %s, unlock error: %s", p.Path, err)
+			}
+		}()
+		return p.Next()
+	}
+}
+
+// ApiLockKey key of input fields with `lock:"{name}"` tag, empty name matches all lock tags,
+// fields of embedded structs are included, zero values are rejected, fields must be scalar (or pointers to)
+func ApiLockKey(name string) func(in any) (any, error) {
+	return func(in any) (any, error) {
+		rIn := reflect.Indirect(reflect.ValueOf(in))
+		if rIn.Kind() != reflect.Struct {
+			return nil, ErrApiLockKeyEmpty
+		}
+
+		indexes, err := getApiLockFields(rIn.Type(), name)
+		if err != nil {
+			return nil, err
+		}
+
+		var keys []any
+		for _, index := range indexes {
+			field, err := rIn.FieldByIndexErr(index)
+			if err != nil {
+				return nil, ErrApiLockKeyEmpty
+			}
+			field = reflect.Indirect(field)
+			if !field.IsValid() || field.IsZero() {
+				return nil, ErrApiLockKeyEmpty
+			}
+			keys = append(keys, field.Interface())
+		}
+		if len(keys) == 0 {
+			return nil, ErrApiLockKeyEmpty
+		}
+		return util.MakeStrKey(keys...), nil
+	}
+}
+
+func getApiLockFields(rType reflect.Type, name string) ([][]int, error) {
+	cacheKey := apiLockFieldsKey{rType, name}
+	if fields, ok := apiLockFields.Load(cacheKey); ok {
+		fields := fields.(*apiLockFieldIndexes)
+		return fields.indexes, fields.err
+	}
+
+	fields := new(apiLockFieldIndexes)
+	fields.indexes, fields.err = findApiLockFields(rType, name, nil)
+	apiLockFields.Store(cacheKey, fields)
+	return fields.indexes, fields.err
+}
+
+// findApiLockFields tagged fields, descend into untagged embedded structs
+func findApiLockFields(rType reflect.Type, name string, parent []int) (indexes [][]int, err error) {
+	for i := 0; i < rType.NumField(); i++ {
+		field := rType.Field(i)
+		index := append(slices.Clone(parent), i)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		tag, ok := field.Tag.Lookup(lockTagName)
+		if ok {
+			if name != "" && tag != name {
+				continue
+			}
+			if !isApiLockScalar(fieldType.Kind()) {
+				return nil, errx.Sprintf("lock field %s.%s: %s is not scalar", rType, field.Name, field.Type)
+			}
+			indexes = append(indexes, index)
+			continue
+		}
+
+		if field.Anonymous && fieldType.Kind() == reflect.Struct {
+			embedded, err := findApiLockFields(fieldType, name, index)
+			if err != nil {
+				return nil, err
+			}
+			indexes = append(indexes, embedded...)
+		}
+	}
+	return
+}
+
+func isApiLockScalar(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
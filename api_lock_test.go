@@ -0,0 +1,106 @@
+package ark
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/arklib/ark/errx"
+	"github.com/arklib/ark/lock"
+	lockdriver "github.com/arklib/ark/lock/driver"
+	"github.com/arklib/ark/util"
+)
+
+type (
+	lockTestBase struct {
+		OrgId int `lock:"id"`
+	}
+
+	lockTestIn struct {
+		*lockTestBase
+		UserId string  `lock:"id"`
+		Ref    *string `lock:"ref"`
+		Name   string
+	}
+
+	lockTestNoTag struct {
+		Name string
+	}
+
+	lockTestStruct struct {
+		Base lockTestBase `lock:"id"`
+	}
+)
+
+func TestApiLockKey(t *testing.T) {
+	ref := "a"
+	empty := ""
+	tests := []struct {
+		name string
+		tag  string
+		in   any
+		want any
+		err  error
+	}{
+		{"tagged", "ref", &lockTestIn{Ref: &ref}, lockTestKey("a"), nil},
+		{"embedded", "id", &lockTestIn{lockTestBase: &lockTestBase{OrgId: 1}, UserId: "u"}, lockTestKey(1, "u"), nil},
+		{"all tags", "", &lockTestIn{lockTestBase: &lockTestBase{OrgId: 1}, UserId: "u", Ref: &ref}, lockTestKey(1, "u", "a"), nil},
+		{"nil pointer", "ref", &lockTestIn{}, nil, ErrApiLockKeyEmpty},
+		{"nil embedded", "id", &lockTestIn{UserId: "u"}, nil, ErrApiLockKeyEmpty},
+		{"empty string", "id", &lockTestIn{lockTestBase: &lockTestBase{OrgId: 1}}, nil, ErrApiLockKeyEmpty},
+		{"empty string pointer", "ref", &lockTestIn{Ref: &empty}, nil, ErrApiLockKeyEmpty},
+		{"zero int", "id", &lockTestIn{lockTestBase: &lockTestBase{}, UserId: "u"}, nil, ErrApiLockKeyEmpty},
+		{"no tag", "", &lockTestNoTag{Name: "a"}, nil, ErrApiLockKeyEmpty},
+		{"not struct", "", "a", nil, ErrApiLockKeyEmpty},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ApiLockKey(tt.tag)(tt.in)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error: want %v, got %v", tt.err, err)
+			}
+			if key != tt.want {
+				t.Fatalf("key: want %v, got %v", tt.want, key)
+			}
+		})
+	}
+
+	// non-scalar fields are rejected at tag resolving
+	_, err := ApiLockKey("id")(&lockTestStruct{Base: lockTestBase{OrgId: 1}})
+	if err == nil || errors.Is(err, ErrApiLockKeyEmpty) {
+		t.Fatalf("struct field: want type error, got %v", err)
+	}
+}
+
+func TestApiLockContention(t *testing.T) {
+	l := lock.Define(lock.Config{Driver: lockdriver.NewMemoryDriver(), Name: "api", TTL: 10})
+	mw := ApiLock(ApiLockConfig{Lock: l, Key: ApiLockKey("id")})
+
+	ctx := newCtx(context.Background(), &Server{}, nil)
+	in := &lockTestIn{lockTestBase: &lockTestBase{OrgId: 1}, UserId: "u"}
+
+	// hold the lock in the handler, a concurrent call gets 409
+	var inner error
+	err := mw(&ApiPayload{Ctx: ctx, In: in, Next: func() error {
+		inner = mw(&ApiPayload{Ctx: ctx, In: in, Next: func() error { return nil }})
+		return nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var appErr *errx.AppError
+	if !errors.As(inner, &appErr) || appErr.Code() != 409 {
+		t.Fatalf("contention: want 409, got %v", inner)
+	}
+
+	// unlocked after the handler
+	if err = mw(&ApiPayload{Ctx: ctx, In: in, Next: func() error { return nil }}); err != nil {
+		t.Fatalf("after unlock: %s", err)
+	}
+}
+
+// lockTestKey expected key of values
+func lockTestKey(keys ...any) any {
+	return util.MakeStrKey(keys...)
+}
@@ -1,99 +1,620 @@
 package cache
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
 	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/samber/lo"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/arklib/ark/debug"
+	"github.com/arklib/ark/lock"
+	"github.com/arklib/ark/metrics"
 	"github.com/arklib/ark/serializer"
-	"github.com/arklib/ark/util"
 )
 
 var ErrKeyType = errors.New("key type error")
 
+// ErrNotFound drivers return on cache miss
+var ErrNotFound = errors.New("cache not found")
+
+var ErrTagDisabled = errors.New("cache tags are disabled")
+
+// notFoundValue negative cache marker
+var notFoundValue = []byte("\x00ark:cache:not_found")
+
+// errNotFoundCached internal, negative cached keys skip load
+var errNotFoundCached = errors.New("cache not found cached")
+
+// staleHeader stale-while-revalidate envelope: header + soft expire (unix nano) + data
+var staleHeader = []byte("\x00ark:cache:swr:")
+
+const lockPollInterval = 50 * time.Millisecond
+
 type (
 	Driver interface {
 		Set(ctx context.Context, key string, data []byte, ttl time.Duration) error
+		// Get returns ErrNotFound on miss
 		Get(ctx context.Context, key string) (data []byte, err error)
 		Del(ctx context.Context, key string) error
 	}
 
+	// BatchDriver optional batch methods of Driver, fallback to one key per call
+	BatchDriver interface {
+		// MGet returns hits only
+		MGet(ctx context.Context, keys []string) (map[string][]byte, error)
+		MSet(ctx context.Context, items map[string][]byte, ttl time.Duration) error
+		MDel(ctx context.Context, keys []string) error
+	}
+
+	// SetNXDriver optional Driver method, tag versions are created once by concurrent callers
+	SetNXDriver interface {
+		// SetNX set only if the key not exists, returns whether set
+		SetNX(ctx context.Context, key string, data []byte, ttl time.Duration) (bool, error)
+	}
+
 	Config struct {
 		Driver     Driver
 		Serializer serializer.Serializer
 		Name       string
 		TTL        uint
+		// negative cache ttl (second) of not found loads, 0 disabled
+		NotFoundTTL uint
+		// cross-instance load lock of GetOrSet, nil disabled
+		Lock *lock.Lock
+		// max wait (second) for other instance load
+		LockWait uint
+		// max time (second) of GetOrSet load, shared by concurrent callers, default 10
+		LoadTimeout uint
+		// versioned keys & tags, enable InvalidateTag and Clear, one more driver read per call
+		UseTags bool
+		// soft ttl (second) less than TTL, stale data served with background refresh after it, 0 disabled
+		SoftTTL uint
+		// max random ttl (second) added to TTL
+		TTLJitter uint
 	}
 
 	Cache[Data any] struct {
-		driver     Driver
-		serializer serializer.Serializer
-		name       string
-		ttl        time.Duration
+		driver      Driver
+		serializer  serializer.Serializer
+		name        string
+		ttl         time.Duration
+		notFoundTTL time.Duration
+		lock        *lock.Lock
+		lockWait    time.Duration
+		loadTimeout time.Duration
+		group       *singleflight.Group
+		useTags     bool
+		softTTL     time.Duration
+		ttlJitter   time.Duration
+		refreshing  *sync.Map
 	}
 )
 
+// Define panics on invalid config
 func Define[Data any](c Config) Cache[Data] {
+	if c.SoftTTL > 0 && (c.TTL == 0 || c.SoftTTL >= c.TTL) {
+		panic(fmt.Sprintf("cache %s: SoftTTL must be less than TTL", c.Name))
+	}
+	// entries of old versions are left to expire
+	if c.UseTags && c.TTL == 0 {
+		panic(fmt.Sprintf("cache %s: UseTags requires TTL", c.Name))
+	}
 	if c.Serializer == nil {
 		c.Serializer = serializer.NewGoJson()
 	}
+	if _, err := c.Serializer.Encode(new(Data)); err != nil {
+		panic(fmt.Sprintf("cache %s: serializer can not encode data: %s", c.Name, err))
+	}
+
+	if c.LockWait == 0 {
+		c.LockWait = 3
+	}
+	if c.LoadTimeout == 0 {
+		c.LoadTimeout = 10
+	}
 
 	return Cache[Data]{
-		driver:     c.Driver,
-		serializer: c.Serializer,
-		name:       c.Name,
-		ttl:        time.Duration(c.TTL) * time.Second,
+		driver:      c.Driver,
+		serializer:  c.Serializer,
+		name:        c.Name,
+		ttl:         time.Duration(c.TTL) * time.Second,
+		notFoundTTL: time.Duration(c.NotFoundTTL) * time.Second,
+		lock:        c.Lock,
+		lockWait:    time.Duration(c.LockWait) * time.Second,
+		loadTimeout: time.Duration(c.LoadTimeout) * time.Second,
+		group:       new(singleflight.Group),
+		useTags:     c.UseTags,
+		softTTL:     time.Duration(c.SoftTTL) * time.Second,
+		ttlJitter:   time.Duration(c.TTLJitter) * time.Second,
+		refreshing:  new(sync.Map),
 	}
 }
 
-func (c *Cache[Data]) Set(ctx context.Context, key any, data *Data) error {
-	newKey := util.MakeStrKey(c.name, key)
-	if newKey == "" {
-		return ErrKeyType
+// Set with optional tags, tags require Config.UseTags
+func (c *Cache[Data]) Set(ctx context.Context, key any, data *Data, tags ...string) error {
+	if len(tags) > 0 && !c.useTags {
+		return ErrTagDisabled
 	}
 
-	newData, err := c.serializer.Encode(data)
+	newKey, err := c.makeKey(ctx, key)
 	if err != nil {
 		return err
 	}
-	return c.driver.Set(ctx, newKey, newData, c.ttl)
+
+	envelope, err := c.tagEnvelope(ctx, tags)
+	if err != nil {
+		return err
+	}
+	newData, err := c.encode(data)
+	if err != nil {
+		return err
+	}
+	return c.driver.Set(ctx, newKey, slices.Concat(envelope, newData), c.getTTL())
 }
 
+// Get returns ErrNotFound on miss or negative cached, stale data is returned as well
 func (c *Cache[Data]) Get(ctx context.Context, key any) (*Data, error) {
-	newKey := util.MakeStrKey(c.name, key)
-	if newKey == "" {
-		return nil, ErrKeyType
+	data, _, err := c.get(ctx, key)
+	return data, notFound(err)
+}
+
+func (c *Cache[Data]) get(ctx context.Context, key any) (data *Data, stale bool, err error) {
+	newKey, err := c.makeKey(ctx, key)
+	if err != nil {
+		return
 	}
 
 	rawData, err := c.driver.Get(ctx, newKey)
+	if err == nil {
+		rawData, err = c.unwrapTag(ctx, newKey, rawData)
+	}
+	switch {
+	case err == nil:
+		metrics.ObserveCache(c.name, true)
+	case errors.Is(err, ErrNotFound):
+		metrics.ObserveCache(c.name, false)
+		return
+	default:
+		return
+	}
+
+	if bytes.Equal(rawData, notFoundValue) {
+		err = errNotFoundCached
+		return
+	}
+
+	data, stale, err = c.decode(rawData)
+	if stale {
+		metrics.ObserveCacheStale(c.name)
+	}
+	return
+}
+
+// GetOrSet load by handler on miss, concurrent loads of the same key are collapsed.
+// handler returns ErrNotFound (or nil data) for not found, cached with NotFoundTTL.
+// stale data is returned and refreshed by handler in background.
+func (c *Cache[Data]) GetOrSet(ctx context.Context, key any, handler func() (*Data, error)) (*Data, error) {
+	data, stale, err := c.get(ctx, key)
+	if !errors.Is(err, ErrNotFound) {
+		if stale {
+			c.refresh(ctx, key, handler)
+		}
+		return data, notFound(err)
+	}
+
+	// load is not canceled by the first caller, every caller waits with its own ctx
+	newKey, err := c.makeKey(ctx, key)
 	if err != nil {
 		return nil, err
 	}
+	loadCtx := context.WithoutCancel(ctx)
+	ch := c.group.DoChan(newKey, func() (any, error) {
+		ctx, cancel := context.WithTimeout(loadCtx, c.loadTimeout)
+		defer cancel()
+		return c.load(ctx, key, newKey, handler)
+	})
 
-	data := new(Data)
-	err = c.serializer.Decode(rawData, data)
-	return data, err
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, notFound(res.Err)
+		}
+		return res.Val.(*Data), nil
+	}
 }
 
-func (c *Cache[Data]) GetOrSet(ctx context.Context, key any, handler func() (*Data, error)) (*Data, error) {
-	data, err := c.Get(ctx, key)
+func (c *Cache[Data]) load(ctx context.Context, key any, newKey string, handler func() (*Data, error)) (*Data, error) {
+	if c.lock != nil {
+		payload, err := c.lock.Lock(ctx, newKey)
+		switch {
+		case err == nil:
+			defer func() { _ = payload.Unlock() }()
+
+			// double check, loaded by other instance
+			data, _, err := c.get(ctx, key)
+			if !errors.Is(err, ErrNotFound) {
+				return data, err
+			}
+		case errors.Is(err, lock.ErrIsLocked):
+			// wait other instance load
+			data, err := c.wait(ctx, key)
+			if !errors.Is(err, ErrNotFound) {
+				return data, err
+			}
+		}
+	}
+
+	return c.store(ctx, key, newKey, handler)
+}
+
+// refresh stale data in background, once per key of the instance, once across instances with lock
+func (c *Cache[Data]) refresh(ctx context.Context, key any, handler func() (*Data, error)) {
+	newKey, err := c.makeKey(ctx, key)
+	if err != nil {
+		return
+	}
+	if _, loaded := c.refreshing.LoadOrStore(newKey, struct{}{}); loaded {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer c.refreshing.Delete(newKey)
+		c.refreshDone(newKey, func() error {
+			if c.lock != nil {
+				payload, err := c.lock.Lock(ctx, newKey)
+				if errors.Is(err, lock.ErrIsLocked) {
+					// refreshing by other instance
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				defer func() { _ = payload.Unlock() }()
+			}
+
+			_, err := c.store(ctx, key, newKey, handler)
+			if errors.Is(err, ErrNotFound) {
+				return nil
+			}
+			return err
+		})
+	}()
+}
+
+// refreshDone run background refresh, errors and panics are logged and observed, stale data is kept
+func (c *Cache[Data]) refreshDone(keys string, refresh func() error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[cache.refresh] name: %s, keys: %s, panic: %v\n%s", c.name, keys, r, debug.Stack(3))
+			metrics.ObserveCacheRefreshError(c.name)
+		}
+	}()
+
+	if err := refresh(); err != nil {
+		log.Printf("[cache.refresh] name: %s, keys: %s, error: %s\n", c.name, keys, err)
+		metrics.ObserveCacheRefreshError(c.name)
+	}
+}
+
+// store load by handler and set
+func (c *Cache[Data]) store(ctx context.Context, key any, newKey string, handler func() (*Data, error)) (*Data, error) {
+	data, err := handler()
+	if errors.Is(err, ErrNotFound) || (err == nil && data == nil) {
+		if c.notFoundTTL > 0 {
+			err = c.driver.Set(ctx, newKey, notFoundValue, c.notFoundTTL)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	data, err = handler()
+	rawData, err := c.encode(data)
 	if err != nil {
 		return nil, err
 	}
+	return data, c.driver.Set(ctx, newKey, rawData, c.getTTL())
+}
+
+func (c *Cache[Data]) wait(ctx context.Context, key any) (*Data, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.lockWait)
+	defer cancel()
+
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ErrNotFound
+		case <-ticker.C:
+			data, _, err := c.get(ctx, key)
+			if !errors.Is(err, ErrNotFound) {
+				return data, err
+			}
+		}
+	}
+}
 
-	err = c.Set(ctx, key, data)
-	return data, err
+// notFound map internal errNotFoundCached to ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, errNotFoundCached) {
+		return ErrNotFound
+	}
+	return err
 }
 
 func (c *Cache[Data]) Del(ctx context.Context, key any) error {
-	newKey := util.MakeStrKey(c.name, key)
-	if newKey == "" {
-		return ErrKeyType
+	newKey, err := c.makeKey(ctx, key)
+	if err != nil {
+		return err
 	}
 	return c.driver.Del(ctx, newKey)
 }
+
+// MGet returns hits (include stale) by key and misses (include negative cached)
+func (c *Cache[Data]) MGet(ctx context.Context, keys []any) (hits map[any]*Data, misses []any, err error) {
+	hits, misses, notFounds, _, err := c.mget(ctx, keys)
+	if err != nil {
+		return
+	}
+	misses = append(misses, notFounds...)
+	return
+}
+
+// MGetOrSet load all misses by one loader call, keys not returned by loader are not found.
+// stale data is returned and refreshed by loader in background.
+func (c *Cache[Data]) MGetOrSet(ctx context.Context, keys []any, loader func(misses []any) (map[any]*Data, error)) (map[any]*Data, error) {
+	hits, misses, _, stales, err := c.mget(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	if len(stales) > 0 {
+		c.mrefresh(ctx, stales, loader)
+	}
+	if len(misses) == 0 {
+		return hits, nil
+	}
+
+	loaded, err := c.mstore(ctx, misses, loader)
+	if err != nil {
+		return nil, err
+	}
+	for key, data := range loaded {
+		hits[key] = data
+	}
+	return hits, nil
+}
+
+// mrefresh stale data in background, keys refreshing by the instance are skipped
+func (c *Cache[Data]) mrefresh(ctx context.Context, keys []any, loader func(misses []any) (map[any]*Data, error)) {
+	newKeys, err := c.makeKeys(ctx, keys)
+	if err != nil {
+		return
+	}
+
+	var refreshKeys []any
+	var refreshNewKeys []string
+	for i, key := range keys {
+		newKey := newKeys[i]
+		if _, loaded := c.refreshing.LoadOrStore(newKey, struct{}{}); !loaded {
+			refreshKeys = append(refreshKeys, key)
+			refreshNewKeys = append(refreshNewKeys, newKey)
+		}
+	}
+	if len(refreshKeys) == 0 {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer func() {
+			for _, newKey := range refreshNewKeys {
+				c.refreshing.Delete(newKey)
+			}
+		}()
+		c.refreshDone(strings.Join(refreshNewKeys, ","), func() error {
+			_, err := c.mstore(ctx, refreshKeys, loader)
+			return err
+		})
+	}()
+}
+
+// mstore load by loader and set, returns found data
+func (c *Cache[Data]) mstore(ctx context.Context, keys []any, loader func(misses []any) (map[any]*Data, error)) (map[any]*Data, error) {
+	newKeys, err := c.makeKeys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded, err := loader(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make(map[any]*Data, len(loaded))
+	items := make(map[string][]byte, len(keys))
+	notFounds := make(map[string][]byte)
+	for i, key := range keys {
+		newKey := newKeys[i]
+		data, ok := loaded[key]
+		if !ok || data == nil {
+			notFounds[newKey] = notFoundValue
+			continue
+		}
+
+		rawData, err := c.encode(data)
+		if err != nil {
+			return nil, err
+		}
+		items[newKey] = rawData
+		hits[key] = data
+	}
+
+	if err = c.mset(ctx, items, c.getTTL()); err != nil {
+		return nil, err
+	}
+	if c.notFoundTTL > 0 {
+		if err = c.mset(ctx, notFounds, c.notFoundTTL); err != nil {
+			return nil, err
+		}
+	}
+	return hits, nil
+}
+
+// MSet with optional tags, tags require Config.UseTags
+func (c *Cache[Data]) MSet(ctx context.Context, items map[any]*Data, tags ...string) error {
+	if len(tags) > 0 && !c.useTags {
+		return ErrTagDisabled
+	}
+
+	keys := lo.Keys(items)
+	newKeys, err := c.makeKeys(ctx, keys)
+	if err != nil {
+		return err
+	}
+	envelope, err := c.tagEnvelope(ctx, tags)
+	if err != nil {
+		return err
+	}
+
+	newItems := make(map[string][]byte, len(items))
+	for i, key := range keys {
+		rawData, err := c.encode(items[key])
+		if err != nil {
+			return err
+		}
+		newItems[newKeys[i]] = slices.Concat(envelope, rawData)
+	}
+	return c.mset(ctx, newItems, c.getTTL())
+}
+
+func (c *Cache[Data]) MDel(ctx context.Context, keys []any) error {
+	newKeys, err := c.makeKeys(ctx, keys)
+	if err != nil || len(newKeys) == 0 {
+		return err
+	}
+
+	if batch, ok := c.driver.(BatchDriver); ok {
+		return batch.MDel(ctx, newKeys)
+	}
+	for _, newKey := range newKeys {
+		if err = c.driver.Del(ctx, newKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Cache[Data]) mget(ctx context.Context, keys []any) (hits map[any]*Data, misses, notFounds, stales []any, err error) {
+	newKeys, err := c.makeKeys(ctx, keys)
+	if err != nil {
+		return
+	}
+
+	hits = make(map[any]*Data, len(keys))
+	if len(newKeys) == 0 {
+		return
+	}
+
+	rawItems, err := c.getItems(ctx, newKeys)
+	if err != nil {
+		return
+	}
+	if err = c.unwrapTags(ctx, rawItems); err != nil {
+		return
+	}
+
+	for i, key := range keys {
+		rawData, ok := rawItems[newKeys[i]]
+		metrics.ObserveCache(c.name, ok)
+		switch {
+		case !ok:
+			misses = append(misses, key)
+		case bytes.Equal(rawData, notFoundValue):
+			notFounds = append(notFounds, key)
+		default:
+			data, stale, err := c.decode(rawData)
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
+			if stale {
+				metrics.ObserveCacheStale(c.name)
+				stales = append(stales, key)
+			}
+			hits[key] = data
+		}
+	}
+	return
+}
+
+func (c *Cache[Data]) mset(ctx context.Context, items map[string][]byte, ttl time.Duration) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	if batch, ok := c.driver.(BatchDriver); ok {
+		if err := batch.MSet(ctx, items, ttl); err != nil {
+			return err
+		}
+	} else {
+		for newKey, rawData := range items {
+			if err := c.driver.Set(ctx, newKey, rawData, ttl); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getTTL ttl with random jitter, one ttl per batch set
+func (c *Cache[Data]) getTTL() time.Duration {
+	if c.ttl == 0 || c.ttlJitter == 0 {
+		return c.ttl
+	}
+	return c.ttl + rand.N(c.ttlJitter+1)
+}
+
+// encode with stale envelope when SoftTTL enabled
+func (c *Cache[Data]) encode(data *Data) ([]byte, error) {
+	rawData, err := c.serializer.Encode(data)
+	if err != nil || c.softTTL == 0 {
+		return rawData, err
+	}
+
+	n := len(staleHeader)
+	buf := make([]byte, n+8+len(rawData))
+	copy(buf, staleHeader)
+	binary.BigEndian.PutUint64(buf[n:], uint64(time.Now().Add(c.softTTL).UnixNano()))
+	copy(buf[n+8:], rawData)
+	return buf, nil
+}
+
+// decode data without stale envelope are always fresh
+func (c *Cache[Data]) decode(rawData []byte) (data *Data, stale bool, err error) {
+	n := len(staleHeader)
+	if len(rawData) >= n+8 && bytes.Equal(rawData[:n], staleHeader) {
+		softExpireAt := int64(binary.BigEndian.Uint64(rawData[n:]))
+		stale = time.Now().UnixNano() > softExpireAt
+		rawData = rawData[n+8:]
+	}
+
+	data = new(Data)
+	err = c.serializer.Decode(rawData, data)
+	return
+}
@@ -0,0 +1,291 @@
+package driver
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/arklib/ark/cache"
+)
+
+const (
+	PolicyLRU = "lru"
+	PolicyLFU = "lfu"
+)
+
+var ErrEntryTooLarge = errors.New("cache entry too large")
+
+type (
+	MemoryConfig struct {
+		// eviction policy: lru | lfu, default lru
+		Policy string
+		// max entries, 0 unlimited
+		MaxEntries int
+		// max bytes of keys and data, 0 unlimited
+		MaxBytes int64
+		// expired entries clean interval, default 1m
+		CleanInterval time.Duration
+	}
+
+	MemoryStats struct {
+		Hits      uint64 `json:"hits"`
+		Misses    uint64 `json:"misses"`
+		Evictions uint64 `json:"evictions"`
+		Expired   uint64 `json:"expired"`
+		Entries   int    `json:"entries"`
+		Bytes     int64  `json:"bytes"`
+	}
+
+	MemoryDriver struct {
+		cache.Driver
+		config  MemoryConfig
+		mu      sync.Mutex
+		entries map[string]*memoryEntry
+		queue   *memoryQueue
+		bytes   int64
+		tick    uint64
+		stats   MemoryStats
+		stopCh  chan struct{}
+		once    sync.Once
+		// lfu dynamic aging, freq of the last evicted entry, new entries start above it
+		age uint64
+	}
+
+	memoryEntry struct {
+		key      string
+		data     []byte
+		expireAt time.Time
+		freq     uint64
+		tick     uint64
+		index    int
+	}
+
+	// memoryQueue eviction min-heap, top is the next entry to evict
+	memoryQueue struct {
+		items []*memoryEntry
+		lfu   bool
+	}
+)
+
+func NewMemoryDriver(config MemoryConfig) *MemoryDriver {
+	if config.Policy == "" {
+		config.Policy = PolicyLRU
+	}
+	if config.CleanInterval <= 0 {
+		config.CleanInterval = time.Minute
+	}
+
+	d := &MemoryDriver{
+		config:  config,
+		entries: make(map[string]*memoryEntry),
+		queue:   &memoryQueue{lfu: config.Policy == PolicyLFU},
+		stopCh:  make(chan struct{}),
+	}
+	go d.cleaner()
+	return d
+}
+
+func (d *MemoryDriver) Set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	if d.config.MaxBytes > 0 && int64(len(key)+len(data)) > d.config.MaxBytes {
+		return ErrEntryTooLarge
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.set(key, data, ttl)
+	return nil
+}
+
+func (d *MemoryDriver) SetNX(ctx context.Context, key string, data []byte, ttl time.Duration) (bool, error) {
+	if d.config.MaxBytes > 0 && int64(len(key)+len(data)) > d.config.MaxBytes {
+		return false, ErrEntryTooLarge
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if e, ok := d.entries[key]; ok && !e.expired(time.Now()) {
+		return false, nil
+	}
+	d.set(key, data, ttl)
+	return true, nil
+}
+
+func (d *MemoryDriver) Get(ctx context.Context, key string) ([]byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	e, ok := d.entries[key]
+	if ok && e.expired(time.Now()) {
+		d.remove(e)
+		d.stats.Expired++
+		ok = false
+	}
+	if !ok {
+		d.stats.Misses++
+		return nil, cache.ErrNotFound
+	}
+
+	d.tick++
+	e.freq++
+	e.tick = d.tick
+	heap.Fix(d.queue, e.index)
+	d.stats.Hits++
+	return append([]byte(nil), e.data...), nil
+}
+
+func (d *MemoryDriver) Del(ctx context.Context, key string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if e, ok := d.entries[key]; ok {
+		d.remove(e)
+	}
+	return nil
+}
+
+// Flush delete all entries
+func (d *MemoryDriver) Flush(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.entries = make(map[string]*memoryEntry)
+	d.queue.items = nil
+	d.bytes = 0
+	d.age = 0
+	return nil
+}
+
+func (d *MemoryDriver) Stats() MemoryStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := d.stats
+	stats.Entries = len(d.entries)
+	stats.Bytes = d.bytes
+	return stats
+}
+
+// Close stop background clean
+func (d *MemoryDriver) Close() {
+	d.once.Do(func() { close(d.stopCh) })
+}
+
+// evict over limit entries, except the entry just set
+func (d *MemoryDriver) evict(keep *memoryEntry) {
+	heap.Remove(d.queue, keep.index)
+	defer heap.Push(d.queue, keep)
+
+	for d.queue.Len() > 0 {
+		overEntries := d.config.MaxEntries > 0 && len(d.entries) > d.config.MaxEntries
+		overBytes := d.config.MaxBytes > 0 && d.bytes > d.config.MaxBytes
+		if !overEntries && !overBytes {
+			return
+		}
+		e := d.queue.items[0]
+		d.age = e.freq
+		d.remove(e)
+		d.stats.Evictions++
+	}
+}
+
+// set locked by caller
+func (d *MemoryDriver) set(key string, data []byte, ttl time.Duration) {
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = time.Now().Add(ttl)
+	}
+
+	d.tick++
+	e, ok := d.entries[key]
+	if ok {
+		d.bytes += int64(len(data) - len(e.data))
+		e.data = append([]byte(nil), data...)
+		e.expireAt = expireAt
+		e.freq++
+		e.tick = d.tick
+		heap.Fix(d.queue, e.index)
+	} else {
+		e = &memoryEntry{
+			key:      key,
+			data:     append([]byte(nil), data...),
+			expireAt: expireAt,
+			freq:     d.age + 1,
+			tick:     d.tick,
+		}
+		d.entries[key] = e
+		d.bytes += int64(len(key) + len(data))
+		heap.Push(d.queue, e)
+	}
+	d.evict(e)
+}
+
+func (d *MemoryDriver) remove(e *memoryEntry) {
+	heap.Remove(d.queue, e.index)
+	delete(d.entries, e.key)
+	d.bytes -= int64(len(e.key) + len(e.data))
+}
+
+func (d *MemoryDriver) cleaner() {
+	ticker := time.NewTicker(d.config.CleanInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stopCh:
+			return
+		case <-ticker.C:
+			d.clean()
+		}
+	}
+}
+
+func (d *MemoryDriver) clean() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for _, e := range d.entries {
+		if e.expired(now) {
+			d.remove(e)
+			d.stats.Expired++
+		}
+	}
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && now.After(e.expireAt)
+}
+
+func (q *memoryQueue) Len() int { return len(q.items) }
+
+func (q *memoryQueue) Less(i, j int) bool {
+	a, b := q.items[i], q.items[j]
+	if q.lfu && a.freq != b.freq {
+		return a.freq < b.freq
+	}
+	return a.tick < b.tick
+}
+
+func (q *memoryQueue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+	q.items[i].index = i
+	q.items[j].index = j
+}
+
+func (q *memoryQueue) Push(x any) {
+	e := x.(*memoryEntry)
+	e.index = len(q.items)
+	q.items = append(q.items, e)
+}
+
+func (q *memoryQueue) Pop() any {
+	n := len(q.items)
+	e := q.items[n-1]
+	q.items[n-1] = nil
+	q.items = q.items[:n-1]
+	e.index = -1
+	return e
+}
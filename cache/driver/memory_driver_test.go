@@ -0,0 +1,167 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/arklib/ark/cache"
+	"github.com/arklib/ark/cache/driver"
+)
+
+func TestMemoryDriverTTL(t *testing.T) {
+	d := newMemoryDriver(t, driver.MemoryConfig{})
+	ctx := context.Background()
+
+	mustSet(t, d, "a", "1", 20*time.Millisecond)
+	mustSet(t, d, "b", "2", 0)
+	expectGet(t, d, "a", "1")
+
+	time.Sleep(40 * time.Millisecond)
+	if _, err := d.Get(ctx, "a"); !errors.Is(err, cache.ErrNotFound) {
+		t.Fatalf("get expired: want ErrNotFound, got %v", err)
+	}
+	expectGet(t, d, "b", "2")
+
+	if stats := d.Stats(); stats.Expired != 1 || stats.Entries != 1 {
+		t.Fatalf("stats: want 1 expired & 1 entry, got %+v", stats)
+	}
+}
+
+func TestMemoryDriverLRU(t *testing.T) {
+	d := newMemoryDriver(t, driver.MemoryConfig{Policy: driver.PolicyLRU, MaxEntries: 2})
+
+	mustSet(t, d, "a", "1", 0)
+	mustSet(t, d, "b", "2", 0)
+	expectGet(t, d, "a", "1")
+	mustSet(t, d, "c", "3", 0)
+
+	// b is the least recently used
+	expectMiss(t, d, "b")
+	expectGet(t, d, "a", "1")
+	expectGet(t, d, "c", "3")
+}
+
+func TestMemoryDriverLFU(t *testing.T) {
+	d := newMemoryDriver(t, driver.MemoryConfig{Policy: driver.PolicyLFU, MaxEntries: 2})
+
+	mustSet(t, d, "a", "1", 0)
+	mustSet(t, d, "b", "2", 0)
+	for i := 0; i < 3; i++ {
+		expectGet(t, d, "a", "1")
+	}
+
+	// new entry is admitted, b is the least frequently used
+	mustSet(t, d, "c", "3", 0)
+	expectGet(t, d, "c", "3")
+	expectMiss(t, d, "b")
+
+	// hot a is kept, c is evicted by the next new entry
+	mustSet(t, d, "d", "4", 0)
+	expectGet(t, d, "d", "4")
+	expectGet(t, d, "a", "1")
+	expectMiss(t, d, "c")
+}
+
+func TestMemoryDriverMaxBytes(t *testing.T) {
+	d := newMemoryDriver(t, driver.MemoryConfig{MaxBytes: 10})
+	ctx := context.Background()
+
+	if err := d.Set(ctx, "big", []byte("0123456789"), 0); !errors.Is(err, driver.ErrEntryTooLarge) {
+		t.Fatalf("set too large: want ErrEntryTooLarge, got %v", err)
+	}
+
+	// 4 bytes each (key & data)
+	mustSet(t, d, "a", "111", 0)
+	mustSet(t, d, "b", "222", 0)
+	mustSet(t, d, "c", "333", 0)
+	expectMiss(t, d, "a")
+	expectGet(t, d, "b", "222")
+	expectGet(t, d, "c", "333")
+
+	if stats := d.Stats(); stats.Bytes != 8 || stats.Entries != 2 || stats.Evictions != 1 {
+		t.Fatalf("stats: want 8 bytes, 2 entries & 1 eviction, got %+v", stats)
+	}
+}
+
+func TestMemoryDriverStats(t *testing.T) {
+	d := newMemoryDriver(t, driver.MemoryConfig{})
+	ctx := context.Background()
+
+	mustSet(t, d, "a", "1", 0)
+	expectGet(t, d, "a", "1")
+	expectMiss(t, d, "b")
+
+	// returned data is a copy
+	data, _ := d.Get(ctx, "a")
+	data[0] = '2'
+	expectGet(t, d, "a", "1")
+
+	if err := d.Del(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	want := driver.MemoryStats{Hits: 3, Misses: 1}
+	if stats := d.Stats(); stats != want {
+		t.Fatalf("stats: want %+v, got %+v", want, stats)
+	}
+}
+
+func TestMemoryDriverConcurrent(t *testing.T) {
+	d := newMemoryDriver(t, driver.MemoryConfig{Policy: driver.PolicyLFU, MaxEntries: 50, MaxBytes: 1000})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				key := fmt.Sprintf("key%d", (i*j)%100)
+				switch j % 4 {
+				case 0:
+					_ = d.Set(ctx, key, []byte(key), time.Millisecond*time.Duration(j%3))
+				case 3:
+					_ = d.Del(ctx, key)
+				default:
+					_, _ = d.Get(ctx, key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if stats := d.Stats(); stats.Entries > 50 || stats.Bytes > 1000 {
+		t.Fatalf("stats over limit: %+v", stats)
+	}
+}
+
+func newMemoryDriver(t *testing.T, config driver.MemoryConfig) *driver.MemoryDriver {
+	d := driver.NewMemoryDriver(config)
+	t.Cleanup(d.Close)
+	return d
+}
+
+func mustSet(t *testing.T, d *driver.MemoryDriver, key, data string, ttl time.Duration) {
+	t.Helper()
+	if err := d.Set(context.Background(), key, []byte(data), ttl); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectGet(t *testing.T, d *driver.MemoryDriver, key, want string) {
+	t.Helper()
+	data, err := d.Get(context.Background(), key)
+	if err != nil || string(data) != want {
+		t.Fatalf("get %s: want %s, got %s %v", key, want, data, err)
+	}
+}
+
+func expectMiss(t *testing.T, d *driver.MemoryDriver, key string) {
+	t.Helper()
+	if _, err := d.Get(context.Background(), key); !errors.Is(err, cache.ErrNotFound) {
+		t.Fatalf("get %s: want ErrNotFound, got %v", key, err)
+	}
+}
@@ -2,6 +2,7 @@ package driver
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -23,9 +24,110 @@ func (r *RedisDriver) Set(ctx context.Context, key string, data []byte, ttl time
 }
 
 func (r *RedisDriver) Get(ctx context.Context, key string) (data []byte, err error) {
-	return r.client.Get(ctx, key).Bytes()
+	data, err = r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		err = cache.ErrNotFound
+	}
+	return
+}
+
+// GetTTL data with remaining ttl, 0 never expire
+func (r *RedisDriver) GetTTL(ctx context.Context, key string) (data []byte, ttl time.Duration, err error) {
+	var getCmd *redis.StringCmd
+	var ttlCmd *redis.DurationCmd
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		getCmd = pipe.Get(ctx, key)
+		ttlCmd = pipe.PTTL(ctx, key)
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return nil, 0, cache.ErrNotFound
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	data, _ = getCmd.Bytes()
+	ttl = ttlCmd.Val()
+	if ttl < 0 {
+		ttl = 0
+	}
+	return
+}
+
+func (r *RedisDriver) SetNX(ctx context.Context, key string, data []byte, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, data, ttl).Result()
 }
 
 func (r *RedisDriver) Del(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
+
+func (r *RedisDriver) MGet(ctx context.Context, keys []string) (map[string][]byte, error) {
+	if r.isCluster() {
+		return r.pipeGet(ctx, keys)
+	}
+
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make(map[string][]byte, len(values))
+	for i, value := range values {
+		if str, ok := value.(string); ok {
+			items[keys[i]] = []byte(str)
+		}
+	}
+	return items, nil
+}
+
+func (r *RedisDriver) MSet(ctx context.Context, items map[string][]byte, ttl time.Duration) error {
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, data := range items {
+			pipe.Set(ctx, key, data, ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+func (r *RedisDriver) MDel(ctx context.Context, keys []string) error {
+	if r.isCluster() {
+		_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				pipe.Del(ctx, key)
+			}
+			return nil
+		})
+		return err
+	}
+	return r.client.Del(ctx, keys...).Err()
+}
+
+// isCluster multi-key commands of cluster fail with CROSSSLOT, use pipelines of one key commands
+func (r *RedisDriver) isCluster() bool {
+	_, ok := r.client.(*redis.ClusterClient)
+	return ok
+}
+
+func (r *RedisDriver) pipeGet(ctx context.Context, keys []string) (map[string][]byte, error) {
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Get(ctx, key)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	items := make(map[string][]byte, len(keys))
+	for i, cmd := range cmds {
+		if data, err := cmd.Bytes(); err == nil {
+			items[keys[i]] = data
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,166 @@
+package driver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/arklib/ark/cache"
+)
+
+const DefaultInvalidateChannel = "ark:cache:invalidate"
+
+type (
+	TwoLevelConfig struct {
+		// local tier, like MemoryDriver
+		Local cache.Driver
+		// remote tier, like RedisDriver
+		Remote cache.Driver
+		// pub/sub client of invalidation
+		Client redis.UniversalClient
+		// pub/sub channel, default DefaultInvalidateChannel
+		Channel string
+		// local ttl, default 1m, capped by remote ttl (remaining ttl on remote hits of RedisDriver)
+		LocalTTL time.Duration
+	}
+
+	TwoLevelDriver struct {
+		cache.Driver
+		local    cache.Driver
+		remote   cache.Driver
+		client   redis.UniversalClient
+		channel  string
+		localTTL time.Duration
+		id       string
+		pubSub   *redis.PubSub
+	}
+
+	// ttlGetter remote drivers return remaining ttl, like RedisDriver
+	ttlGetter interface {
+		GetTTL(ctx context.Context, key string) ([]byte, time.Duration, error)
+	}
+)
+
+func NewTwoLevelDriver(config TwoLevelConfig) *TwoLevelDriver {
+	if config.Channel == "" {
+		config.Channel = DefaultInvalidateChannel
+	}
+	if config.LocalTTL <= 0 {
+		config.LocalTTL = time.Minute
+	}
+
+	d := &TwoLevelDriver{
+		local:    config.Local,
+		remote:   config.Remote,
+		client:   config.Client,
+		channel:  config.Channel,
+		localTTL: config.LocalTTL,
+		id:       newInstanceId(),
+	}
+	d.pubSub = d.client.Subscribe(context.Background(), d.channel)
+	go d.subscribe()
+	return d
+}
+
+func (d *TwoLevelDriver) Set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	err := d.remote.Set(ctx, key, data, ttl)
+	if err != nil {
+		return err
+	}
+
+	err = d.local.Set(ctx, key, data, d.getLocalTTL(ttl))
+	if err != nil {
+		return err
+	}
+	return d.publish(ctx, key)
+}
+
+// SetNX by remote, set as Set when remote not support
+func (d *TwoLevelDriver) SetNX(ctx context.Context, key string, data []byte, ttl time.Duration) (bool, error) {
+	remote, ok := d.remote.(cache.SetNXDriver)
+	if !ok {
+		return true, d.Set(ctx, key, data, ttl)
+	}
+
+	set, err := remote.SetNX(ctx, key, data, ttl)
+	if err != nil || !set {
+		return set, err
+	}
+
+	err = d.local.Set(ctx, key, data, d.getLocalTTL(ttl))
+	if err != nil {
+		return true, err
+	}
+	return true, d.publish(ctx, key)
+}
+
+func (d *TwoLevelDriver) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := d.local.Get(ctx, key)
+	if err == nil {
+		return data, nil
+	}
+
+	// local ttl capped by remote remaining ttl
+	var ttl time.Duration
+	if remote, ok := d.remote.(ttlGetter); ok {
+		data, ttl, err = remote.GetTTL(ctx, key)
+	} else {
+		data, err = d.remote.Get(ctx, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_ = d.local.Set(ctx, key, data, d.getLocalTTL(ttl))
+	return data, nil
+}
+
+func (d *TwoLevelDriver) Del(ctx context.Context, key string) error {
+	err := d.remote.Del(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	err = d.local.Del(ctx, key)
+	if err != nil {
+		return err
+	}
+	return d.publish(ctx, key)
+}
+
+// Close stop invalidation subscribe
+func (d *TwoLevelDriver) Close() error {
+	return d.pubSub.Close()
+}
+
+func (d *TwoLevelDriver) getLocalTTL(ttl time.Duration) time.Duration {
+	if ttl > 0 && ttl < d.localTTL {
+		return ttl
+	}
+	return d.localTTL
+}
+
+// publish message: {instance id}:{key}
+func (d *TwoLevelDriver) publish(ctx context.Context, key string) error {
+	return d.client.Publish(ctx, d.channel, d.id+":"+key).Err()
+}
+
+func (d *TwoLevelDriver) subscribe() {
+	for msg := range d.pubSub.Channel() {
+		id, key, ok := strings.Cut(msg.Payload, ":")
+		if !ok || id == d.id {
+			continue
+		}
+		_ = d.local.Del(context.Background(), key)
+	}
+}
+
+func newInstanceId() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
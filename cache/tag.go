@@ -0,0 +1,262 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/arklib/ark/util"
+)
+
+// tagHeader tag envelope: header + count + (tag, version)... + data, lengths are uvarint
+var tagHeader = []byte("\x00ark:cache:tags:")
+
+var errTagEnvelope = errors.New("cache tag envelope error")
+
+// makeKey with namespace version when UseTags enabled, Clear bumps the version
+func (c *Cache[Data]) makeKey(ctx context.Context, key any) (string, error) {
+	newKeys, err := c.makeKeys(ctx, []any{key})
+	if err != nil {
+		return "", err
+	}
+	return newKeys[0], nil
+}
+
+func (c *Cache[Data]) makeKeys(ctx context.Context, keys []any) ([]string, error) {
+	var ver string
+	if c.useTags {
+		versions, err := c.versions(ctx, []string{c.versionKey("")}, 0)
+		if err != nil {
+			return nil, err
+		}
+		ver = versions[0]
+	}
+
+	newKeys := make([]string, len(keys))
+	for i, key := range keys {
+		if ver == "" {
+			newKeys[i] = util.MakeStrKey(c.name, key)
+		} else {
+			newKeys[i] = util.MakeStrKey(c.name, ver, key)
+		}
+		if newKeys[i] == "" {
+			return nil, ErrKeyType
+		}
+	}
+	return newKeys, nil
+}
+
+// InvalidateTag entries set with the tag become misses
+func (c *Cache[Data]) InvalidateTag(ctx context.Context, tag string) error {
+	if !c.useTags {
+		return ErrTagDisabled
+	}
+	return c.driver.Set(ctx, c.versionKey(tag), []byte(newVersion()), c.tagTTL())
+}
+
+// Clear entries under the name namespace become misses, old entries expire by TTL
+func (c *Cache[Data]) Clear(ctx context.Context) error {
+	if !c.useTags {
+		return ErrTagDisabled
+	}
+	return c.driver.Set(ctx, c.versionKey(""), []byte(newVersion()), 0)
+}
+
+// versions get versions of version keys, missing (expired or evicted) are created,
+// which invalidates entries of the old version
+func (c *Cache[Data]) versions(ctx context.Context, versionKeys []string, ttl time.Duration) ([]string, error) {
+	items, err := c.getItems(ctx, versionKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]string, len(versionKeys))
+	for i, versionKey := range versionKeys {
+		if ver, ok := items[versionKey]; ok {
+			versions[i] = string(ver)
+			continue
+		}
+
+		if versions[i], err = c.createVersion(ctx, versionKey, ttl); err != nil {
+			return nil, err
+		}
+	}
+	return versions, nil
+}
+
+// createVersion set once, concurrent callers get the same version by SetNXDriver
+func (c *Cache[Data]) createVersion(ctx context.Context, versionKey string, ttl time.Duration) (string, error) {
+	ver := newVersion()
+	nx, ok := c.driver.(SetNXDriver)
+	if !ok {
+		return ver, c.driver.Set(ctx, versionKey, []byte(ver), ttl)
+	}
+
+	for {
+		set, err := nx.SetNX(ctx, versionKey, []byte(ver), ttl)
+		if err != nil || set {
+			return ver, err
+		}
+
+		// set by others, retry when expired or invalidated in between
+		current, err := c.driver.Get(ctx, versionKey)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		return string(current), err
+	}
+}
+
+// tagEnvelope of current tag versions, nil without tags
+func (c *Cache[Data]) tagEnvelope(ctx context.Context, tags []string) ([]byte, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	versionKeys := make([]string, len(tags))
+	for i, tag := range tags {
+		versionKeys[i] = c.versionKey(tag)
+	}
+	versions, err := c.versions(ctx, versionKeys, c.tagTTL())
+	if err != nil {
+		return nil, err
+	}
+
+	buf := append([]byte(nil), tagHeader...)
+	buf = binary.AppendUvarint(buf, uint64(len(tags)))
+	for i, versionKey := range versionKeys {
+		buf = appendBytes(buf, []byte(versionKey))
+		buf = appendBytes(buf, []byte(versions[i]))
+	}
+	return buf, nil
+}
+
+// unwrapTags strip tag envelopes of items, items with invalidated tags are removed
+func (c *Cache[Data]) unwrapTags(ctx context.Context, items map[string][]byte) error {
+	entryTags := make(map[string]map[string]string)
+	var versionKeys []string
+	for newKey, rawData := range items {
+		if !bytes.HasPrefix(rawData, tagHeader) {
+			continue
+		}
+
+		tags, data, err := parseTags(rawData[len(tagHeader):])
+		if err != nil {
+			return err
+		}
+		for versionKey := range tags {
+			versionKeys = append(versionKeys, versionKey)
+		}
+		entryTags[newKey] = tags
+		items[newKey] = data
+	}
+	if len(entryTags) == 0 {
+		return nil
+	}
+
+	versions, err := c.getItems(ctx, versionKeys)
+	if err != nil {
+		return err
+	}
+	for newKey, tags := range entryTags {
+		for versionKey, ver := range tags {
+			if current, ok := versions[versionKey]; !ok || string(current) != ver {
+				delete(items, newKey)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// unwrapTag one item of unwrapTags, returns ErrNotFound when invalidated
+func (c *Cache[Data]) unwrapTag(ctx context.Context, newKey string, rawData []byte) ([]byte, error) {
+	items := map[string][]byte{newKey: rawData}
+	if err := c.unwrapTags(ctx, items); err != nil {
+		return nil, err
+	}
+
+	rawData, ok := items[newKey]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return rawData, nil
+}
+
+// getItems by batch driver or one key per call, returns hits only
+func (c *Cache[Data]) getItems(ctx context.Context, newKeys []string) (map[string][]byte, error) {
+	if batch, ok := c.driver.(BatchDriver); ok {
+		return batch.MGet(ctx, newKeys)
+	}
+
+	items := make(map[string][]byte, len(newKeys))
+	for _, newKey := range newKeys {
+		rawData, err := c.driver.Get(ctx, newKey)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		items[newKey] = rawData
+	}
+	return items, nil
+}
+
+// versionKey empty tag is the namespace version
+func (c *Cache[Data]) versionKey(tag string) string {
+	if tag == "" {
+		return util.MakeStrKey(c.name, "@ver")
+	}
+	return util.MakeStrKey(c.name, "@ver", tag)
+}
+
+// tagTTL tag versions outlive entries set with them
+func (c *Cache[Data]) tagTTL() time.Duration {
+	return 2 * (c.ttl + c.ttlJitter)
+}
+
+func parseTags(buf []byte) (tags map[string]string, data []byte, err error) {
+	n, size := binary.Uvarint(buf)
+	if size <= 0 {
+		return nil, nil, errTagEnvelope
+	}
+	buf = buf[size:]
+
+	tags = make(map[string]string, n)
+	for i := uint64(0); i < n; i++ {
+		var versionKey, ver []byte
+		if versionKey, buf, err = readBytes(buf); err != nil {
+			return
+		}
+		if ver, buf, err = readBytes(buf); err != nil {
+			return
+		}
+		tags[string(versionKey)] = string(ver)
+	}
+	return tags, buf, nil
+}
+
+func appendBytes(buf, b []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+func readBytes(buf []byte) (b, rest []byte, err error) {
+	n, size := binary.Uvarint(buf)
+	if size <= 0 || uint64(len(buf)-size) < n {
+		return nil, nil, errTagEnvelope
+	}
+	buf = buf[size:]
+	return buf[:n], buf[n:], nil
+}
+
+func newVersion() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
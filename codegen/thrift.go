@@ -0,0 +1,172 @@
+package codegen
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/arklib/ark/util"
+)
+
+type thriftMethod struct {
+	name    string
+	comment string
+	in      string
+	out     string
+}
+
+type ThriftPackage struct {
+	structs map[string]string
+	methods []*thriftMethod
+}
+
+func NewThriftPackage() *ThriftPackage {
+	return &ThriftPackage{
+		structs: make(map[string]string),
+	}
+}
+
+// ThriftMethodName rpc path "user/get" -> "user_get"
+func ThriftMethodName(path string) string {
+	return strings.NewReplacer("/", "_", "-", "_", ".", "_").Replace(path)
+}
+
+// AddMethod add service method like "Out user_get(1: In req)"
+func (p *ThriftPackage) AddMethod(path, comment string, in, out any) (err error) {
+	m := &thriftMethod{
+		name:    ThriftMethodName(path),
+		comment: comment,
+	}
+	if m.in, err = p.typeOf(reflect.TypeOf(in), ""); err != nil {
+		return
+	}
+	if m.out, err = p.typeOf(reflect.TypeOf(out), ""); err != nil {
+		return
+	}
+	p.methods = append(p.methods, m)
+	return
+}
+
+// typeOf thrift type from go type, slices use frugal type descriptor for set
+func (p *ThriftPackage) typeOf(rType reflect.Type, desc string) (string, error) {
+	switch rType.Kind() {
+	case reflect.Ptr:
+		return p.typeOf(rType.Elem(), desc)
+	case reflect.Bool:
+		return "bool", nil
+	case reflect.Int8:
+		return "byte", nil
+	case reflect.Int16:
+		return "i16", nil
+	case reflect.Int32:
+		return "i32", nil
+	case reflect.Int, reflect.Int64:
+		return "i64", nil
+	case reflect.Float64:
+		return "double", nil
+	case reflect.String:
+		return "string", nil
+	case reflect.Slice:
+		if rType.Elem().Kind() == reflect.Uint8 {
+			return "binary", nil
+		}
+		elem, err := p.typeOf(rType.Elem(), "")
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(strings.TrimSpace(desc), "set") {
+			return fmt.Sprintf("set<%s>", elem), nil
+		}
+		return fmt.Sprintf("list<%s>", elem), nil
+	case reflect.Map:
+		key, err := p.typeOf(rType.Key(), "")
+		if err != nil {
+			return "", err
+		}
+		value, err := p.typeOf(rType.Elem(), "")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("map<%s, %s>", key, value), nil
+	case reflect.Struct:
+		if rType.Name() == "" {
+			return "", fmt.Errorf("anonymous struct not supported in thrift idl: %s", rType)
+		}
+		name := pkgTypeName(rType)
+		if _, ok := p.structs[name]; ok {
+			return name, nil
+		}
+
+		// placeholder first, for recursive types
+		p.structs[name] = ""
+		code, err := p.structOf(name, rType)
+		if err != nil {
+			return "", err
+		}
+		p.structs[name] = code
+		return name, nil
+	default:
+		return "", fmt.Errorf("unsupported thrift type: %s", rType)
+	}
+}
+
+func (p *ThriftPackage) structOf(name string, rType reflect.Type) (string, error) {
+	code := fmt.Sprintf("struct %s {\n", name)
+	for i := 0; i < rType.NumField(); i++ {
+		field := rType.Field(i)
+
+		// frugal tag like `frugal:"1,required,list<string>"`
+		tag, ok := field.Tag.Lookup("frugal")
+		if !ok || field.Anonymous || !field.IsExported() {
+			continue
+		}
+
+		parts := strings.SplitN(tag, ",", 3)
+		if len(parts) < 2 {
+			return "", fmt.Errorf("invalid frugal tag: %s.%s", rType, field.Name)
+		}
+
+		id, spec, desc := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), ""
+		if len(parts) == 3 {
+			desc = parts[2]
+		}
+
+		fieldType, err := p.typeOf(field.Type, desc)
+		if err != nil {
+			return "", fmt.Errorf("%s.%s: %w", rType, field.Name, err)
+		}
+
+		if spec == "default" {
+			spec = ""
+		} else {
+			spec += " "
+		}
+
+		fieldName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if fieldName == "" || fieldName == "-" {
+			fieldName = lo.CamelCase(field.Name)
+		}
+		code += fmt.Sprintf("    %s: %s%s %s\n", id, spec, fieldType, fieldName)
+	}
+	return code + "}\n", nil
+}
+
+func (p *ThriftPackage) Source(service string) string {
+	codes := []string{"// Code generated by ark. DO NOT EDIT.\n"}
+
+	util.ForEachMapBySort(p.structs, func(_ string, code string) {
+		codes = append(codes, code)
+	})
+
+	code := fmt.Sprintf("service %s {\n", lo.PascalCase(service))
+	for _, m := range p.methods {
+		if m.comment != "" {
+			code += fmt.Sprintf("    // %s\n", m.comment)
+		}
+		code += fmt.Sprintf("    %s %s(1: %s req)\n", m.out, m.name, m.in)
+	}
+	codes = append(codes, code+"}\n")
+	return strings.Join(codes, "\n")
+}
@@ -159,3 +159,14 @@ func (t *Type) write(code string, a ...any) *Type {
 func (t *Type) writeln(code string, a ...any) *Type {
 	return t.write(code+"\n", a...)
 }
+
+// pkgTypeName pkg.Type -> PkgType
+func pkgTypeName(rType reflect.Type) string {
+	_, pkgName := util.SplitSuffix(rType.PkgPath(), "/")
+	name := rType.Name()
+	// generic type name like Page[pkg/path.Item]
+	if strings.Contains(name, "[") {
+		name = lo.PascalCase(name)
+	}
+	return lo.PascalCase(pkgName) + name
+}
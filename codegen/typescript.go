@@ -0,0 +1,276 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/arklib/ark/util"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// bindTags request binding tags before json
+var bindTags = []string{"path", "query", "form"}
+
+const tsClientCode = `export class ApiError extends Error {
+  constructor(public code: number, message: string) {
+    super(message);
+  }
+}
+
+export interface Result<T> {
+  code: number;
+  message?: string;
+  data?: T;
+}
+
+export class Client {
+  constructor(public baseURL = "", public init: RequestInit = {}) {}
+
+  async request<In, Out>(method: string, path: string, input: In, queryKeys: string[] = []): Promise<Out> {
+    const params: Record<string, any> = { ...(input as any) };
+    path = path.replace(/[:*](\w+)/g, (_, name) => {
+      const value = params[name];
+      delete params[name];
+      return encodeURIComponent(String(value));
+    });
+
+    const noBody = method === "GET" || method === "HEAD" || method === "DELETE";
+    const query = new URLSearchParams();
+    for (const [key, value] of Object.entries(params)) {
+      if (!noBody && !queryKeys.includes(key)) continue;
+      delete params[key];
+      if (value === undefined || value === null) continue;
+      for (const v of Array.isArray(value) ? value : [value]) {
+        // nested objects are bound from json text
+        query.append(key, typeof v === "object" && v !== null ? JSON.stringify(v) : String(v));
+      }
+    }
+
+    let url = this.baseURL + "/" + path;
+    const qs = query.toString();
+    if (qs) url += "?" + qs;
+
+    const init: RequestInit = { ...this.init, method };
+    if (!noBody) {
+      init.headers = { "Content-Type": "application/json", ...(this.init.headers || {}) };
+      init.body = JSON.stringify(params);
+    }
+
+    const resp = await fetch(url, init);
+    let res: Result<Out>;
+    try {
+      res = await resp.json();
+    } catch {
+      throw new ApiError(resp.status, resp.statusText);
+    }
+    if (res.code !== 200) {
+      throw new ApiError(res.code, res.message || "");
+    }
+    return res.data as Out;
+  }
+`
+
+type tsMethod struct {
+	name      string
+	method    string
+	path      string
+	comment   string
+	in        string
+	out       string
+	queryKeys []string
+}
+
+type TSPackage struct {
+	types   map[string]string
+	methods []*tsMethod
+}
+
+func NewTSPackage() *TSPackage {
+	return &TSPackage{
+		types: make(map[string]string),
+	}
+}
+
+// AddMethod add client method, path like "user/:id"
+func (p *TSPackage) AddMethod(name, method, path, comment string, in, out any) {
+	p.methods = append(p.methods, &tsMethod{
+		name:      name,
+		method:    strings.ToUpper(method),
+		path:      path,
+		comment:   comment,
+		in:        p.AddType(in),
+		out:       p.AddType(out),
+		queryKeys: p.queryKeys(reflect.TypeOf(in)),
+	})
+}
+
+// AddType add value type, returns ts type expression
+func (p *TSPackage) AddType(val any) string {
+	return p.typeOf(reflect.TypeOf(val), "")
+}
+
+func (p *TSPackage) typeOf(rType reflect.Type, indent string) string {
+	// nil interface value
+	if rType == nil {
+		return "any"
+	}
+	if rType == timeType {
+		return "string"
+	}
+
+	switch rType.Kind() {
+	case reflect.Ptr:
+		return p.typeOf(rType.Elem(), indent)
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		// []byte encode as base64 string
+		if rType.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		return p.typeOf(rType.Elem(), indent) + "[]"
+	case reflect.Map:
+		return fmt.Sprintf("Record<string, %s>", p.typeOf(rType.Elem(), indent))
+	case reflect.Struct:
+		if rType.Name() == "" {
+			return p.structOf(rType, indent)
+		}
+
+		name := pkgTypeName(rType)
+		if _, ok := p.types[name]; !ok {
+			// placeholder first, for recursive types
+			p.types[name] = ""
+			p.types[name] = fmt.Sprintf("export interface %s %s\n", name, p.structOf(rType, ""))
+		}
+		return name
+	default:
+		return "any"
+	}
+}
+
+// queryKeys fields bound from query of body methods
+func (p *TSPackage) queryKeys(rType reflect.Type) (keys []string) {
+	for rType != nil && rType.Kind() == reflect.Ptr {
+		rType = rType.Elem()
+	}
+	if rType == nil || rType.Kind() != reflect.Struct {
+		return
+	}
+
+	p.eachField(rType, func(field reflect.StructField, name string, optional bool) {
+		if tagName(field.Tag, "query") == name {
+			keys = append(keys, name)
+		}
+	})
+	return
+}
+
+func (p *TSPackage) structOf(rType reflect.Type, indent string) string {
+	code := "{\n"
+	p.eachField(rType, func(field reflect.StructField, name string, optional bool) {
+		fieldType := p.typeOf(field.Type, indent+"  ")
+		if optional {
+			name += "?"
+		}
+		code += fmt.Sprintf("%s  %s: %s;\n", indent, name, fieldType)
+	})
+	return code + indent + "}"
+}
+
+// eachField walk fields by binding name (path, query, form, json), embedded structs are flattened
+func (p *TSPackage) eachField(rType reflect.Type, fn func(reflect.StructField, string, bool)) {
+	for i := 0; i < rType.NumField(); i++ {
+		field := rType.Field(i)
+
+		tag, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		for _, key := range bindTags {
+			if name := tagName(field.Tag, key); name != "" {
+				tag = name
+				break
+			}
+		}
+		if tag == "-" {
+			continue
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && tag == "" && fieldType.Kind() == reflect.Struct {
+			p.eachField(fieldType, fn)
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		name := tag
+		if name == "" {
+			name = field.Name
+		}
+		optional := field.Type.Kind() == reflect.Ptr || strings.Contains(opts, "omitempty")
+		fn(field, name, optional)
+	}
+}
+
+// Source routes of the same path with other methods are named with method, like getUserId & deleteUserId
+func (p *TSPackage) Source() (string, error) {
+	codes := []string{"// Code generated by ark. DO NOT EDIT.\n"}
+
+	counts := lo.CountValuesBy(p.methods, func(m *tsMethod) string { return lo.CamelCase(m.name) })
+	names := make([]string, len(p.methods))
+	seen := make(map[string]*tsMethod)
+	for i, m := range p.methods {
+		name := lo.CamelCase(m.name)
+		if counts[name] > 1 {
+			name = lo.CamelCase(strings.ToLower(m.method) + "_" + m.name)
+		}
+		if other, ok := seen[name]; ok {
+			return "", fmt.Errorf("ts method %s duplicated: %s %s, %s %s", name, other.method, other.path, m.method, m.path)
+		}
+		seen[name] = m
+		names[i] = name
+	}
+
+	util.ForEachMapBySort(p.types, func(_ string, code string) {
+		codes = append(codes, code)
+	})
+
+	client := tsClientCode
+	for i, m := range p.methods {
+		name := names[i]
+		client += "\n"
+		if m.comment != "" {
+			client += fmt.Sprintf("  // %s\n", m.comment)
+		}
+		client += fmt.Sprintf("  %s(input: %s): Promise<%s> {\n", name, m.in, m.out)
+		if len(m.queryKeys) > 0 && m.method != "GET" && m.method != "HEAD" && m.method != "DELETE" {
+			keys, _ := json.Marshal(m.queryKeys)
+			client += fmt.Sprintf("    return this.request(%q, %q, input, %s);\n", m.method, m.path, keys)
+		} else {
+			client += fmt.Sprintf("    return this.request(%q, %q, input);\n", m.method, m.path)
+		}
+		client += "  }\n"
+	}
+	codes = append(codes, client+"}\n")
+	return strings.Join(codes, "\n"), nil
+}
+
+func tagName(tag reflect.StructTag, key string) string {
+	name, _, _ := strings.Cut(tag.Get(key), ",")
+	return name
+}
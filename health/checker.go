@@ -0,0 +1,55 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Pinger drivers support health check, like queue drivers
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+func Ping(p Pinger) Checker {
+	return p.Ping
+}
+
+func Redis(client redis.Cmdable) Checker {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}
+}
+
+func DB(db *gorm.DB) Checker {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// Dial any of the addrs is reachable, like registry addrs
+func Dial(addrs ...string) Checker {
+	return func(ctx context.Context) error {
+		if len(addrs) == 0 {
+			return errors.New("no addrs")
+		}
+
+		var dialer net.Dialer
+		var err error
+		for _, addr := range addrs {
+			var conn net.Conn
+			conn, err = dialer.DialContext(ctx, "tcp", addr)
+			if err == nil {
+				return conn.Close()
+			}
+		}
+		return err
+	}
+}
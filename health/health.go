@@ -0,0 +1,131 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+var ErrShutdown = errors.New("server is shutting down")
+
+type (
+	Checker func(ctx context.Context) error
+
+	Result struct {
+		Status  string  `json:"status"`
+		Latency float64 `json:"latencyMs"`
+		Error   string  `json:"error,omitempty"`
+	}
+
+	Report struct {
+		Status string             `json:"status"`
+		Error  string             `json:"error,omitempty"`
+		Checks map[string]*Result `json:"checks,omitempty"`
+	}
+
+	Health struct {
+		mu       sync.RWMutex
+		checkers map[string]Checker
+		shutdown atomic.Bool
+		Timeout  time.Duration
+	}
+)
+
+func New() *Health {
+	return &Health{
+		checkers: make(map[string]Checker),
+		Timeout:  5 * time.Second,
+	}
+}
+
+// Add named checker, same name replaces the old one
+func (h *Health) Add(name string, checker Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkers[name] = checker
+}
+
+func (h *Health) Remove(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.checkers, name)
+}
+
+// Shutdown mark not ready
+func (h *Health) Shutdown() {
+	h.shutdown.Store(true)
+}
+
+func (h *Health) IsShutdown() bool {
+	return h.shutdown.Load()
+}
+
+// Live liveness report, without checkers
+func (h *Health) Live() *Report {
+	return &Report{Status: StatusUp}
+}
+
+// Ready run all checkers concurrently
+func (h *Health) Ready(ctx context.Context) *Report {
+	if h.IsShutdown() {
+		return &Report{Status: StatusDown, Error: ErrShutdown.Error()}
+	}
+
+	h.mu.RLock()
+	checkers := make(map[string]Checker, len(h.checkers))
+	for name, checker := range h.checkers {
+		checkers[name] = checker
+	}
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	report := &Report{
+		Status: StatusUp,
+		Checks: make(map[string]*Result, len(checkers)),
+	}
+	for name, checker := range checkers {
+		wg.Add(1)
+		go func(name string, checker Checker) {
+			defer wg.Done()
+			result := check(ctx, checker)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status == StatusDown {
+				report.Status = StatusDown
+			}
+		}(name, checker)
+	}
+	wg.Wait()
+	return report
+}
+
+func check(ctx context.Context, checker Checker) (result *Result) {
+	start := time.Now()
+	result = &Result{Status: StatusUp}
+	defer func() {
+		if val := recover(); val != nil {
+			result.Status = StatusDown
+			result.Error = "checker panic"
+		}
+		result.Latency = float64(time.Since(start).Microseconds()) / 1000
+	}()
+
+	if err := checker(ctx); err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return
+}
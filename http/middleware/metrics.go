@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	hz "github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/arklib/ark/metrics"
+)
+
+func Metrics() hz.HandlerFunc {
+	return func(c context.Context, ctx *hz.RequestContext) {
+		start := time.Now()
+		ctx.Next(c)
+		metrics.ObserveHttp(
+			string(ctx.Method()),
+			ctx.FullPath(),
+			ctx.Response.StatusCode(),
+			time.Since(start),
+		)
+	}
+}
@@ -0,0 +1,153 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const Version = "3.0.3"
+
+var pathParamRE = regexp.MustCompile(`[:*](\w+)`)
+
+type (
+	Document struct {
+		OpenAPI    string               `json:"openapi"`
+		Info       Info                 `json:"info"`
+		Paths      map[string]*PathItem `json:"paths"`
+		Components Components           `json:"components"`
+	}
+
+	Info struct {
+		Title       string `json:"title"`
+		Description string `json:"description,omitempty"`
+		Version     string `json:"version"`
+	}
+
+	PathItem map[string]*Operation
+
+	Operation struct {
+		OperationID string               `json:"operationId,omitempty"`
+		Summary     string               `json:"summary,omitempty"`
+		Description string               `json:"description,omitempty"`
+		Tags        []string             `json:"tags,omitempty"`
+		Parameters  []*Parameter         `json:"parameters,omitempty"`
+		RequestBody *RequestBody         `json:"requestBody,omitempty"`
+		Responses   map[string]*Response `json:"responses"`
+	}
+
+	Parameter struct {
+		Name        string  `json:"name"`
+		In          string  `json:"in"`
+		Description string  `json:"description,omitempty"`
+		Required    bool    `json:"required,omitempty"`
+		Schema      *Schema `json:"schema"`
+	}
+
+	RequestBody struct {
+		Required bool                  `json:"required,omitempty"`
+		Content  map[string]*MediaType `json:"content"`
+	}
+
+	Response struct {
+		Description string                `json:"description"`
+		Content     map[string]*MediaType `json:"content,omitempty"`
+	}
+
+	MediaType struct {
+		Schema *Schema `json:"schema"`
+	}
+
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas,omitempty"`
+	}
+
+	Route struct {
+		Method   string
+		Path     string
+		Title    string
+		Describe string
+		Name     string
+		Tag      string
+		Input    any
+		Output   any
+	}
+)
+
+func New(title, version string) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:   title,
+			Version: version,
+		},
+		Paths: make(map[string]*PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+		},
+	}
+}
+
+// AddRoute add operation, request from input struct, response wrapped in result envelope
+func (d *Document) AddRoute(r Route) {
+	method := strings.ToLower(r.Method)
+	path := "/" + pathParamRE.ReplaceAllString(strings.Trim(r.Path, "/"), "{$1}")
+
+	op := &Operation{
+		OperationID: r.Name,
+		Summary:     r.Title,
+		Description: r.Describe,
+		Responses: map[string]*Response{
+			"200": {
+				Description: "OK",
+				Content:     jsonContent(ResultSchema(d.SchemaOf(reflect.TypeOf(r.Output)))),
+			},
+			"default": {
+				Description: "Error",
+				Content:     jsonContent(ResultSchema(nil)),
+			},
+		},
+	}
+	if r.Tag != "" {
+		op.Tags = []string{r.Tag}
+	}
+
+	if r.Input != nil {
+		inBody := method != "get" && method != "head" && method != "delete"
+		op.Parameters, op.RequestBody = d.inputOf(reflect.TypeOf(r.Input), inBody)
+	}
+
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	(*item)[method] = op
+}
+
+func (d *Document) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// ResultSchema result.Result envelope
+func ResultSchema(data *Schema) *Schema {
+	s := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"code":    {Type: "integer"},
+			"message": {Type: "string"},
+		},
+		Required: []string{"code"},
+	}
+	if data != nil {
+		s.Properties["data"] = data
+	}
+	return s
+}
+
+func jsonContent(s *Schema) map[string]*MediaType {
+	return map[string]*MediaType{
+		"application/json": {Schema: s},
+	}
+}
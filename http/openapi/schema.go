@@ -0,0 +1,289 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// parameter binding tags, in order of precedence
+var paramTags = []string{"path", "query", "header", "cookie"}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *uint64            `json:"minLength,omitempty"`
+	MaxLength            *uint64            `json:"maxLength,omitempty"`
+	MinItems             *uint64            `json:"minItems,omitempty"`
+	MaxItems             *uint64            `json:"maxItems,omitempty"`
+}
+
+type field struct {
+	reflect.StructField
+	name string
+}
+
+// SchemaOf build schema, named structs are added to components
+func (d *Document) SchemaOf(rType reflect.Type) *Schema {
+	if rType == nil {
+		return nil
+	}
+	for rType.Kind() == reflect.Ptr {
+		rType = rType.Elem()
+	}
+
+	if rType == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch rType.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if rType.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.SchemaOf(rType.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.SchemaOf(rType.Elem())}
+	case reflect.Struct:
+		if rType.Name() == "" {
+			return d.structOf(rType)
+		}
+
+		name := schemaName(rType)
+		if _, ok := d.Components.Schemas[name]; !ok {
+			// placeholder first, for recursive types
+			s := new(Schema)
+			d.Components.Schemas[name] = s
+			*s = *d.structOf(rType)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		return &Schema{}
+	}
+}
+
+func (d *Document) structOf(rType reflect.Type) *Schema {
+	s := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+	for _, f := range fieldsOf(rType) {
+		d.addProperty(s, f)
+	}
+	return s
+}
+
+func (d *Document) addProperty(s *Schema, f field) {
+	prop := d.SchemaOf(f.Type)
+	if applyRules(prop, f.Type, f.Tag.Get("vd")) {
+		s.Required = append(s.Required, f.name)
+	}
+	if label := f.Tag.Get("label"); label != "" && prop.Ref == "" {
+		prop.Title = label
+	}
+	s.Properties[f.name] = prop
+}
+
+// inputOf split input fields to parameters & request body
+func (d *Document) inputOf(rType reflect.Type, inBody bool) (params []*Parameter, body *RequestBody) {
+	for rType.Kind() == reflect.Ptr {
+		rType = rType.Elem()
+	}
+	if rType.Kind() != reflect.Struct {
+		return
+	}
+
+	bodySchema := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+	for _, f := range fieldsOf(rType) {
+		// bind from auth payload
+		if _, ok := f.Tag.Lookup("auth"); ok {
+			continue
+		}
+
+		in, name := "", ""
+		for _, tag := range paramTags {
+			if value := tagName(f.Tag, tag); value != "" {
+				in, name = tag, value
+				break
+			}
+		}
+		if in == "" && !inBody {
+			in, name = "query", f.name
+		}
+
+		if in == "" {
+			d.addProperty(bodySchema, f)
+			continue
+		}
+
+		schema := d.SchemaOf(f.Type)
+		required := applyRules(schema, f.Type, f.Tag.Get("vd"))
+		params = append(params, &Parameter{
+			Name:        name,
+			In:          in,
+			Description: f.Tag.Get("label"),
+			Required:    required || in == "path",
+			Schema:      schema,
+		})
+	}
+
+	if len(bodySchema.Properties) > 0 {
+		body = &RequestBody{
+			Required: true,
+			Content:  jsonContent(bodySchema),
+		}
+	}
+	return
+}
+
+// fieldsOf exported fields, embedded structs are flattened
+func fieldsOf(rType reflect.Type) (fields []field) {
+	for i := 0; i < rType.NumField(); i++ {
+		f := rType.Field(i)
+
+		name := tagName(f.Tag, "json")
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && name == "" {
+			fType := f.Type
+			if fType.Kind() == reflect.Ptr {
+				fType = fType.Elem()
+			}
+			if fType.Kind() == reflect.Struct {
+				fields = append(fields, fieldsOf(fType)...)
+				continue
+			}
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = tagName(f.Tag, "form")
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, field{f, name})
+	}
+	return
+}
+
+func tagName(tag reflect.StructTag, key string) string {
+	name, _, _ := strings.Cut(tag.Get(key), ",")
+	return name
+}
+
+// applyRules map validator (vd) rules to schema, returns required
+func applyRules(s *Schema, rType reflect.Type, rules string) (required bool) {
+	for rType.Kind() == reflect.Ptr {
+		rType = rType.Elem()
+	}
+
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			// following rules apply to elements
+			return
+		case "required":
+			required = true
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				s.Enum = append(s.Enum, v)
+			}
+		case "email":
+			s.Format = "email"
+		case "url", "uri":
+			s.Format = "uri"
+		case "uuid", "uuid4":
+			s.Format = "uuid"
+		case "ip", "ipv4":
+			s.Format = "ipv4"
+		case "ipv6":
+			s.Format = "ipv6"
+		case "datetime":
+			s.Format = "date-time"
+		case "len":
+			setBound(s, rType, param, true, false)
+			setBound(s, rType, param, false, false)
+		case "min", "gte":
+			setBound(s, rType, param, true, false)
+		case "max", "lte":
+			setBound(s, rType, param, false, false)
+		case "gt":
+			setBound(s, rType, param, true, true)
+		case "lt":
+			setBound(s, rType, param, false, true)
+		}
+	}
+	return
+}
+
+func setBound(s *Schema, rType reflect.Type, param string, isMin, exclusive bool) {
+	switch rType.Kind() {
+	case reflect.String:
+		n := cast.ToUint64(param)
+		if isMin {
+			s.MinLength = &n
+		} else {
+			s.MaxLength = &n
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		n := cast.ToUint64(param)
+		if isMin {
+			s.MinItems = &n
+		} else {
+			s.MaxItems = &n
+		}
+	default:
+		n := cast.ToFloat64(param)
+		if isMin {
+			s.Minimum, s.ExclusiveMinimum = &n, exclusive
+		} else {
+			s.Maximum, s.ExclusiveMaximum = &n, exclusive
+		}
+	}
+}
+
+func schemaName(rType reflect.Type) string {
+	pkgPath := strings.Split(rType.PkgPath(), "/")
+	name := fmt.Sprintf("%s.%s", pkgPath[len(pkgPath)-1], rType.Name())
+	// generic type name like Page[pkg/path.Item]
+	return strings.NewReplacer("[", "_", "]", "", "/", "_", "*", "", ",", "_").Replace(name)
+}
@@ -0,0 +1,36 @@
+package openapi
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+//go:embed swaggerui/swagger-ui-bundle.js swaggerui/swagger-ui.css
+var swaggerUIFiles embed.FS
+
+// SwaggerUIAssets bundled swagger-ui-dist files, served under the page path
+var SwaggerUIAssets, _ = fs.Sub(swaggerUIFiles, "swaggerui")
+
+// SwaggerUI html page, assets are loaded from assetsPath
+func SwaggerUI(title, specURL, assetsPath string) []byte {
+	html := `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <meta name="viewport" content="width=device-width, initial-scale=1" />
+  <title>%[1]s</title>
+  <link rel="stylesheet" href="%[3]s/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="%[3]s/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "%[2]s", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>`
+	return []byte(fmt.Sprintf(html, title, specURL, assetsPath))
+}
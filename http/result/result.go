@@ -6,6 +6,7 @@ import (
 	"github.com/cloudwego/hertz/pkg/protocol/consts"
 
 	"github.com/arklib/ark/errx"
+	"github.com/arklib/ark/metrics"
 )
 
 type Result struct {
@@ -27,6 +28,7 @@ func Error(ctx *hz.RequestContext, err any) {
 	if e.IsBasic() || e.IsFatal() {
 		hlog.DefaultLogger().Error(e.FullError())
 	}
+	metrics.ObserveError("http", e.Code())
 
 	ctx.AbortWithStatusJSON(e.Code(), &Result{
 		Code:    e.Code(),
@@ -0,0 +1,216 @@
+package ark
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	hzcli "github.com/cloudwego/hertz/pkg/app/client"
+	errs "github.com/cloudwego/hertz/pkg/common/errors"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/kitex/pkg/loadbalance"
+	"github.com/cloudwego/kitex/pkg/loadbalance/lbcache"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/arklib/ark/errx"
+	"github.com/arklib/ark/http/result"
+	"github.com/arklib/ark/registry"
+)
+
+// errHttpSent request may have reached the service, POST is not idempotent and not retried
+var errHttpSent = errors.New("http request sent")
+
+// httpRetryStatus gateway errors (without result envelope), retried like unsent requests
+var httpRetryStatus = []int{
+	consts.StatusBadGateway,
+	consts.StatusServiceUnavailable,
+	consts.StatusGatewayTimeout,
+}
+
+type httpClient struct {
+	srv       *Server
+	client    *hzcli.Client
+	balancers *lbcache.BalancerFactory
+}
+
+func newHttpClient(srv *Server) *httpClient {
+	return &httpClient{srv: srv}
+}
+
+func (c *httpClient) init() (err error) {
+	srv := c.srv
+
+	srv.Logger.Debug("[ark] init http client")
+	config := srv.config.HttpClient
+
+	c.client, err = hzcli.NewClient(
+		hzcli.WithDialTimeout(config.Timeout),
+		hzcli.WithClientReadTimeout(config.Timeout),
+		hzcli.WithWriteTimeout(config.Timeout),
+	)
+	if err != nil {
+		return
+	}
+
+	// registry
+	if len(srv.config.Registry.Addrs) > 0 {
+		r, err := registry.NewResolver(srv.config.Registry)
+		if err != nil {
+			return err
+		}
+		balancer := loadbalance.NewWeightedRoundRobinBalancer()
+		c.balancers = lbcache.NewBalancerFactory(r, balancer, lbcache.Options{})
+	}
+	return nil
+}
+
+// resolve service name to host, use name as host without registry
+func (c *httpClient) resolve(ctx context.Context, name string) (string, error) {
+	if c.balancers == nil {
+		return name, nil
+	}
+
+	target := rpcinfo.NewEndpointInfo(name, "", nil, nil)
+	balancer, err := c.balancers.Get(ctx, target)
+	if err != nil {
+		return "", err
+	}
+
+	instance := balancer.GetPicker().Next(ctx, nil)
+	if instance == nil {
+		return "", errx.Sprintf("service instance not found: %s", name)
+	}
+	return instance.Address().String(), nil
+}
+
+// Call http service, path: {service}/{route}.
+// retry (HttpClient.MaxRetry) only when the request is not sent (resolve & dial errors)
+// or a gateway responds 502, 503 or 504 without result envelope, other errors are returned right away.
+func (c *httpClient) Call(ctx context.Context, path string, in, out any) (err error) {
+	paths := strings.SplitN(path, "/", 2)
+	if len(paths) != 2 {
+		return errx.New("service Path error")
+	}
+	name, route := paths[0], paths[1]
+
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	// tracing
+	config := c.srv.config.HttpClient
+	if config.UseTracing {
+		var span trace.Span
+		ctx, span = otel.Tracer("ark").Start(ctx, path, trace.WithSpanKind(trace.SpanKindClient))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	for retry := uint(0); ; retry++ {
+		var host string
+		host, err = c.resolve(ctx, name)
+		if err == nil {
+			err = c.do(ctx, host, route, body, out)
+		}
+		if err == nil || errx.IsAppError(err) || errors.Is(err, errHttpSent) || retry >= config.MaxRetry {
+			return
+		}
+
+		c.srv.Logger.CtxWarnf(ctx, "[http.client] retry: %d, path: %s, error: %s", retry+1, path, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(config.RetryDelay):
+		}
+	}
+}
+
+func (c *httpClient) do(ctx context.Context, host, route string, body []byte, out any) error {
+	req := protocol.AcquireRequest()
+	resp := protocol.AcquireResponse()
+	defer func() {
+		protocol.ReleaseRequest(req)
+		protocol.ReleaseResponse(resp)
+	}()
+
+	req.SetRequestURI(fmt.Sprintf("http://%s/%s", host, route))
+	req.SetMethod(consts.MethodPost)
+	req.Header.SetContentTypeBytes([]byte(consts.MIMEApplicationJSONUTF8))
+	req.SetBody(body)
+
+	// propagate trace context
+	otel.GetTextMapPropagator().Inject(ctx, &httpHeaderCarrier{&req.Header})
+
+	err := c.client.Do(ctx, req, resp)
+	if err != nil {
+		if isConnectError(err) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", errHttpSent, err)
+	}
+
+	// decode result envelope, other responses (like proxy error pages) are basic errors,
+	// gateway errors without envelope are retried
+	res := &result.Result{Data: out}
+	err = json.Unmarshal(resp.Body(), res)
+	if err == nil && res.Code == 0 {
+		err = errors.New("result code missing")
+	}
+	if err != nil {
+		status := resp.StatusCode()
+		if slices.Contains(httpRetryStatus, status) {
+			return fmt.Errorf("http status: %d", status)
+		}
+		return fmt.Errorf("%w: http status: %d, decode result: %w", errHttpSent, status, err)
+	}
+
+	if res.Code != consts.StatusOK {
+		return errx.New(res.Code, res.Message)
+	}
+	return nil
+}
+
+// isConnectError request is not sent
+func isConnectError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return errors.Is(err, errs.ErrNoFreeConns)
+}
+
+// httpHeaderCarrier adapts hertz request header to otel carrier
+type httpHeaderCarrier struct {
+	header *protocol.RequestHeader
+}
+
+func (h *httpHeaderCarrier) Get(key string) string {
+	return h.header.Get(key)
+}
+
+func (h *httpHeaderCarrier) Set(key, value string) {
+	h.header.Set(key, value)
+}
+
+func (h *httpHeaderCarrier) Keys() []string {
+	var keys []string
+	h.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
@@ -0,0 +1,74 @@
+package ark
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/arklib/ark/errx"
+	"github.com/arklib/ark/logger"
+	"github.com/arklib/ark/registry"
+)
+
+func TestHttpClientRetry(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		calls  int32
+		app    bool
+	}{
+		{"gateway error", http.StatusServiceUnavailable, "unavailable", 3, false},
+		{"bad gateway", http.StatusBadGateway, "", 3, false},
+		{"server error", http.StatusInternalServerError, "error page", 1, false},
+		{"result error", http.StatusServiceUnavailable, `{"code":503,"message":"busy"}`, 1, true},
+		{"success", http.StatusOK, `{"code":200,"data":{}}`, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer ts.Close()
+
+			c := newTestHttpClient(t)
+			host := strings.TrimPrefix(ts.URL, "http://")
+			err := c.Call(context.Background(), host+"/test", struct{}{}, &struct{}{})
+
+			if n := calls.Load(); n != tt.calls {
+				t.Errorf("calls: want %d, got %d", tt.calls, n)
+			}
+			var appErr *errx.AppError
+			if isApp := errors.As(err, &appErr); isApp != tt.app {
+				t.Errorf("app error: want %v, got %v (%v)", tt.app, isApp, err)
+			}
+			if tt.status == http.StatusOK && err != nil {
+				t.Errorf("success: %s", err)
+			}
+		})
+	}
+}
+
+func newTestHttpClient(t *testing.T) *httpClient {
+	srv := &Server{
+		config: &ServerConfig{Registry: new(registry.Config)},
+		Logger: logger.NewConsole(&logger.Config{Level: "error"}),
+	}
+	srv.config.HttpClient.MaxRetry = 2
+	srv.config.HttpClient.Timeout = 5 * time.Second
+
+	c := newHttpClient(srv)
+	if err := c.init(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
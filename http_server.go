@@ -1,14 +1,36 @@
 package ark
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	hz "github.com/cloudwego/hertz/pkg/app"
 	hzsrv "github.com/cloudwego/hertz/pkg/app/server"
 	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/kitex/pkg/discovery"
+	keregistry "github.com/cloudwego/kitex/pkg/registry"
 	"github.com/hertz-contrib/cors"
 	"github.com/hertz-contrib/gzip"
 	"github.com/hertz-contrib/logger/accesslog"
 	"github.com/hertz-contrib/pprof"
+	"github.com/prometheus/common/expfmt"
 
+	"github.com/arklib/ark/codegen"
+	"github.com/arklib/ark/health"
 	"github.com/arklib/ark/http/middleware"
+	"github.com/arklib/ark/http/openapi"
+	"github.com/arklib/ark/metrics"
+	"github.com/arklib/ark/registry"
+	"github.com/arklib/ark/util"
 )
 
 type httpServer struct {
@@ -17,17 +39,19 @@ type httpServer struct {
 	srv    *Server
 	hzSrv  *hzsrv.Hertz
 	Routes HttpRoutes
+	Health *health.Health
 }
 
 func newHttpServer(srv *Server) *httpServer {
 	s := &httpServer{
 		HttpRouter: newHttpRouter("", nil),
 		srv:        srv,
+		Health:     health.New(),
 	}
 	return s
 }
 
-func (s *httpServer) init() {
+func (s *httpServer) init() error {
 	srv := s.srv
 	srv.Logger.Debug("[ark] init http server")
 
@@ -67,6 +91,12 @@ func (s *httpServer) init() {
 		srv.Logger.Debug("[http.server] etag enabled")
 	}
 
+	// metrics
+	if srv.config.UseMetrics {
+		hzSrv.Use(middleware.Metrics())
+		srv.Logger.Debug("[http.server] metrics enabled")
+	}
+
 	// access log
 	if config.UseAccessLog {
 		hzSrv.Use(accesslog.New())
@@ -79,12 +109,55 @@ func (s *httpServer) init() {
 		srv.Logger.Debugf("[http.server] fileRoute '%s' -> '%s'", route.Path, route.Root)
 	}
 
+	// registry
+	if len(srv.config.Registry.Addrs) > 0 {
+		if err := s.register(hzSrv); err != nil {
+			return err
+		}
+	}
+
 	s.hzSrv = hzSrv
+	return nil
 }
 
-func (s *httpServer) run() error {
+// register http service for Server.Fetch discovery
+func (s *httpServer) register(hzSrv *hzsrv.Hertz) error {
+	srv := s.srv
+	config := srv.config.HttpServer
+
+	r, err := registry.NewRegistry(srv.config.Registry)
+	if err != nil {
+		return err
+	}
+
+	addr, err := net.ResolveTCPAddr("tcp", config.Addr)
+	if err != nil {
+		return err
+	}
+
+	info := &keregistry.Info{
+		ServiceName: config.Name,
+		Addr:        addr,
+		Weight:      discovery.DefaultWeight,
+		StartTime:   time.Now(),
+	}
+	hzSrv.OnRun = append(hzSrv.OnRun, func(ctx context.Context) error {
+		return r.Register(info)
+	})
+	hzSrv.OnShutdown = append(hzSrv.OnShutdown, func(ctx context.Context) {
+		if err := r.Deregister(info); err != nil {
+			srv.Logger.Errorf("[http.server] deregister error: %s", err)
+		}
+	})
+	srv.Logger.Debugf("[http.server] registry '%s' enabled", config.Name)
+	return nil
+}
+
+func (s *httpServer) setup() error {
 	if s.hzSrv == nil {
-		s.init()
+		if err := s.init(); err != nil {
+			return err
+		}
 	}
 
 	// setup router
@@ -93,9 +166,174 @@ func (s *httpServer) run() error {
 		return err
 	}
 
+	// gen code
+	if s.srv.IsDev() {
+		if err = s.genTSCode(); err != nil {
+			return err
+		}
+	}
+
+	// health endpoints
+	if s.srv.config.HttpServer.UseHealth {
+		s.setupHealth()
+	}
+
+	// metrics endpoint
+	if s.srv.config.UseMetrics {
+		s.hzSrv.GET("/metrics", func(ctx context.Context, c *hz.RequestContext) {
+			buf := new(bytes.Buffer)
+			if err := metrics.Write(buf); err != nil {
+				c.String(consts.StatusInternalServerError, err.Error())
+				return
+			}
+			c.Data(consts.StatusOK, string(expfmt.FmtText), buf.Bytes())
+		})
+	}
+
+	// openapi document
+	return s.setupOpenAPI()
+}
+
+func (s *httpServer) run() error {
 	return s.hzSrv.Run()
 }
 
+func (s *httpServer) setupHealth() {
+	srv := s.srv
+
+	// registry checker
+	if len(srv.config.Registry.Addrs) > 0 {
+		s.Health.Add("registry", health.Dial(srv.config.Registry.Addrs...))
+	}
+
+	s.hzSrv.GET("/healthz", func(ctx context.Context, c *hz.RequestContext) {
+		c.JSON(consts.StatusOK, s.Health.Live())
+	})
+
+	s.hzSrv.GET("/readyz", func(ctx context.Context, c *hz.RequestContext) {
+		report := s.Health.Ready(ctx)
+		code := consts.StatusOK
+		if report.Status != health.StatusUp {
+			code = consts.StatusServiceUnavailable
+		}
+		c.JSON(code, report)
+	})
+	srv.Logger.Debug("[http.server] health enabled")
+}
+
+func (s *httpServer) BuildTSClientCode() (string, error) {
+	pkg := codegen.NewTSPackage()
+	for _, route := range s.Routes {
+		apiProxy := route.Handler
+		pkg.AddMethod(
+			route.FullPath,
+			route.Method,
+			route.FullPath,
+			route.Title,
+			apiProxy.NewInput(),
+			apiProxy.NewOutput(),
+		)
+	}
+	return pkg.Source()
+}
+
+func (s *httpServer) genTSCode() error {
+	config := s.srv.config.HttpServer.UseTSCodeGen
+	if !config.Enable {
+		return nil
+	}
+
+	// client code
+	_, pkgName := util.SplitSuffix(config.Output, "/")
+	code, err := s.BuildTSClientCode()
+	if err != nil {
+		return err
+	}
+
+	// output code file
+	codeFile := fmt.Sprintf("%s/%s.ts", config.Output, pkgName)
+	return os.WriteFile(codeFile, []byte(code), 0666)
+}
+
+func (s *httpServer) BuildOpenAPI() ([]byte, error) {
+	config := s.srv.config.HttpServer
+	doc := openapi.New(config.Name, config.UseOpenAPI.Version)
+
+	for _, route := range s.Routes {
+		tag := route.Router.Title
+		if tag == "" {
+			tag = route.Router.Path
+		}
+
+		apiProxy := route.Handler
+		doc.AddRoute(openapi.Route{
+			Method:   route.Method,
+			Path:     route.FullPath,
+			Title:    route.Title,
+			Describe: route.Describe,
+			Name:     apiProxy.Name,
+			Tag:      tag,
+			Input:    apiProxy.NewInput(),
+			Output:   apiProxy.NewOutput(),
+		})
+	}
+	return doc.JSON()
+}
+
+func (s *httpServer) setupOpenAPI() error {
+	srv := s.srv
+	config := srv.config.HttpServer
+	if !config.UseOpenAPI.Enable {
+		return nil
+	}
+
+	doc, err := s.BuildOpenAPI()
+	if err != nil {
+		return err
+	}
+
+	// export file
+	if srv.IsDev() && config.UseOpenAPI.Output != "" {
+		err = os.WriteFile(config.UseOpenAPI.Output, doc, 0666)
+		if err != nil {
+			return err
+		}
+	}
+
+	docPath := "/" + strings.Trim(config.UseOpenAPI.Path, "/")
+	s.hzSrv.GET(docPath, func(ctx context.Context, c *hz.RequestContext) {
+		c.Data(consts.StatusOK, consts.MIMEApplicationJSONUTF8, doc)
+	})
+	srv.Logger.Debugf("[http.server] openapi '%s'", docPath)
+
+	// swagger ui
+	if config.UseOpenAPI.UseSwaggerUI {
+		uiPath := "/" + strings.Trim(config.UseOpenAPI.SwaggerUIPath, "/")
+		html := openapi.SwaggerUI(config.Name, docPath, uiPath)
+		s.hzSrv.GET(uiPath, func(ctx context.Context, c *hz.RequestContext) {
+			c.Data(consts.StatusOK, consts.MIMETextHtml, html)
+		})
+		s.hzSrv.GET(uiPath+"/:file", func(ctx context.Context, c *hz.RequestContext) {
+			file := c.Param("file")
+			data, err := fs.ReadFile(openapi.SwaggerUIAssets, file)
+			if err != nil {
+				c.AbortWithStatus(consts.StatusNotFound)
+				return
+			}
+			c.Data(consts.StatusOK, mime.TypeByExtension(path.Ext(file)), data)
+		})
+		srv.Logger.Debugf("[http.server] swagger ui '%s'", uiPath)
+	}
+	return nil
+}
+
+func (s *httpServer) shutdown(ctx context.Context) error {
+	if s.hzSrv == nil || !s.hzSrv.IsRunning() {
+		return nil
+	}
+	return s.hzSrv.Shutdown(ctx)
+}
+
 func (s *httpServer) HzServer() *hzsrv.Hertz {
 	return s.hzSrv
 }
@@ -0,0 +1,148 @@
+package driver
+
+import (
+	"context"
+	"math"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/arklib/ark/lock"
+)
+
+const etcdKeyPrefix = "/ark/lock/"
+
+// EtcdDriver lock keys with leases, fencing tokens are create revisions of lock keys
+type EtcdDriver struct {
+	lock.Driver
+	client *clientv3.Client
+}
+
+func NewEtcdDriver(client *clientv3.Client) *EtcdDriver {
+	return &EtcdDriver{client: client}
+}
+
+func (e *EtcdDriver) Lock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	_, ok, err := e.FenceLock(ctx, key, token, ttl)
+	return ok, err
+}
+
+// FenceLock revision of the put is the create revision of the lock key
+func (e *EtcdDriver) FenceLock(ctx context.Context, key string, token string, ttl time.Duration) (uint64, bool, error) {
+	key = etcdKeyPrefix + key
+
+	var opts []clientv3.OpOption
+	var leaseId clientv3.LeaseID
+	if seconds := etcdTTL(ttl); seconds > 0 {
+		lease, err := e.client.Grant(ctx, seconds)
+		if err != nil {
+			return 0, false, err
+		}
+		leaseId = lease.ID
+		opts = append(opts, clientv3.WithLease(leaseId))
+	}
+
+	resp, err := e.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, token, opts...)).
+		Commit()
+	if err == nil && resp.Succeeded {
+		return uint64(resp.Header.Revision), true, nil
+	}
+
+	if leaseId != clientv3.NoLease {
+		_, _ = e.client.Revoke(context.WithoutCancel(ctx), leaseId)
+	}
+	return 0, false, err
+}
+
+func (e *EtcdDriver) Unlock(ctx context.Context, key string, token string) error {
+	key = etcdKeyPrefix + key
+
+	resp, err := e.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.Value(key), "=", token)).
+		Then(clientv3.OpGet(key), clientv3.OpDelete(key)).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if !resp.Succeeded {
+		return lock.ErrNotOwner
+	}
+
+	// revoke the lease of deleted key
+	kvs := resp.Responses[0].GetResponseRange().GetKvs()
+	if len(kvs) > 0 && kvs[0].Lease != 0 {
+		_, _ = e.client.Revoke(ctx, clientv3.LeaseID(kvs[0].Lease))
+	}
+	return nil
+}
+
+// Renew keep alive the lease, or move the key to a new lease when ttl changes
+func (e *EtcdDriver) Renew(ctx context.Context, key string, token string, ttl time.Duration) error {
+	key = etcdKeyPrefix + key
+
+	resp, err := e.client.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if len(resp.Kvs) == 0 || string(resp.Kvs[0].Value) != token {
+		return lock.ErrNotOwner
+	}
+
+	kv := resp.Kvs[0]
+	leaseId := clientv3.LeaseID(kv.Lease)
+	seconds := etcdTTL(ttl)
+	if leaseId == clientv3.NoLease && seconds == 0 {
+		return nil
+	}
+	if leaseId != clientv3.NoLease && seconds > 0 {
+		lease, err := e.client.TimeToLive(ctx, leaseId)
+		if err != nil {
+			return err
+		}
+		if lease.GrantedTTL == seconds {
+			_, err = e.client.KeepAliveOnce(ctx, leaseId)
+			return err
+		}
+	}
+
+	// put the key to the new lease, create revision (fencing token) is kept
+	var opts []clientv3.OpOption
+	newLeaseId := clientv3.NoLease
+	if seconds > 0 {
+		lease, err := e.client.Grant(ctx, seconds)
+		if err != nil {
+			return err
+		}
+		newLeaseId = lease.ID
+		opts = append(opts, clientv3.WithLease(newLeaseId))
+	}
+
+	txn, err := e.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", kv.ModRevision)).
+		Then(clientv3.OpPut(key, token, opts...)).
+		Commit()
+	if err == nil && !txn.Succeeded {
+		err = lock.ErrNotOwner
+	}
+	if err != nil {
+		if newLeaseId != clientv3.NoLease {
+			_, _ = e.client.Revoke(context.WithoutCancel(ctx), newLeaseId)
+		}
+		return err
+	}
+
+	if leaseId != clientv3.NoLease {
+		_, _ = e.client.Revoke(ctx, leaseId)
+	}
+	return nil
+}
+
+// etcdTTL lease seconds, 0 never expire
+func etcdTTL(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return int64(math.Ceil(ttl.Seconds()))
+}
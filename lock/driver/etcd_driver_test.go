@@ -0,0 +1,34 @@
+package driver_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/arklib/ark/lock/driver"
+	"github.com/arklib/ark/lock/locktest"
+)
+
+// TestEtcdDriver runs with ARK_TEST_ETCD_ENDPOINTS, like "127.0.0.1:2379"
+func TestEtcdDriver(t *testing.T) {
+	endpoints := os.Getenv("ARK_TEST_ETCD_ENDPOINTS")
+	if endpoints == "" {
+		t.Skip("ARK_TEST_ETCD_ENDPOINTS not set")
+	}
+
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   strings.Split(endpoints, ","),
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if err = locktest.TestDriver(driver.NewEtcdDriver(client), 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,103 @@
+package driver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/arklib/ark/lock"
+)
+
+type (
+	MemoryDriver struct {
+		lock.Driver
+		mu     sync.Mutex
+		locks  map[string]*memoryLock
+		fences map[string]uint64
+	}
+
+	memoryLock struct {
+		token    string
+		expireAt time.Time
+	}
+)
+
+func NewMemoryDriver() *MemoryDriver {
+	return &MemoryDriver{
+		locks:  make(map[string]*memoryLock),
+		fences: make(map[string]uint64),
+	}
+}
+
+func (m *MemoryDriver) Lock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.lock(key, token, ttl), nil
+}
+
+// FenceLock counter of key increases only when locked
+func (m *MemoryDriver) FenceLock(ctx context.Context, key string, token string, ttl time.Duration) (uint64, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.lock(key, token, ttl) {
+		return 0, false, nil
+	}
+	m.fences[key]++
+	return m.fences[key], true, nil
+}
+
+func (m *MemoryDriver) Unlock(ctx context.Context, key string, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l := m.get(key)
+	if l == nil || l.token != token {
+		return lock.ErrNotOwner
+	}
+	delete(m.locks, key)
+	return nil
+}
+
+func (m *MemoryDriver) Renew(ctx context.Context, key string, token string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l := m.get(key)
+	if l == nil || l.token != token {
+		return lock.ErrNotOwner
+	}
+	l.expireAt = time.Time{}
+	if ttl > 0 {
+		l.expireAt = time.Now().Add(ttl)
+	}
+	return nil
+}
+
+// lock locked by caller
+func (m *MemoryDriver) lock(key string, token string, ttl time.Duration) bool {
+	if m.get(key) != nil {
+		return false
+	}
+
+	l := &memoryLock{token: token}
+	if ttl > 0 {
+		l.expireAt = time.Now().Add(ttl)
+	}
+	m.locks[key] = l
+	return true
+}
+
+// get unexpired lock, expired lock is deleted
+func (m *MemoryDriver) get(key string) *memoryLock {
+	l, ok := m.locks[key]
+	if !ok {
+		return nil
+	}
+	if !l.expireAt.IsZero() && time.Now().After(l.expireAt) {
+		delete(m.locks, key)
+		return nil
+	}
+	return l
+}
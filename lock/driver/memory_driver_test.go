@@ -0,0 +1,15 @@
+package driver_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arklib/ark/lock/driver"
+	"github.com/arklib/ark/lock/locktest"
+)
+
+func TestMemoryDriver(t *testing.T) {
+	if err := locktest.TestDriver(driver.NewMemoryDriver(), time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
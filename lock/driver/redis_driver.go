@@ -2,6 +2,7 @@ package driver
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -9,6 +10,30 @@ import (
 	"github.com/arklib/ark/lock"
 )
 
+// unlockScript compare and delete
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// renewScript compare and expire
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// fenceLockScript set nx and increase the fencing counter, returns 0 locked
+var fenceLockScript = redis.NewScript(`
+if not redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 0
+end
+return redis.call("INCR", KEYS[2])
+`)
+
 type RedisDriver struct {
 	lock.Driver
 	client redis.Cmdable
@@ -18,10 +43,285 @@ func NewRedisDriver(client redis.Cmdable) *RedisDriver {
 	return &RedisDriver{client: client}
 }
 
-func (r *RedisDriver) Lock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
-	return r.client.SetNX(ctx, key, 1, ttl).Result()
+func (r *RedisDriver) Lock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, token, ttl).Result()
+}
+
+func (r *RedisDriver) Unlock(ctx context.Context, key string, token string) error {
+	n, err := unlockScript.Run(ctx, r.client, []string{key}, token).Int()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return lock.ErrNotOwner
+	}
+	return nil
+}
+
+func (r *RedisDriver) Renew(ctx context.Context, key string, token string, ttl time.Duration) error {
+	n, err := renewScript.Run(ctx, r.client, []string{key}, token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return lock.ErrNotOwner
+	}
+	return nil
+}
+
+func (r *RedisDriver) FenceLock(ctx context.Context, key string, token string, ttl time.Duration) (uint64, bool, error) {
+	fence, err := fenceLockScript.Run(ctx, r.client, []string{key, fenceKey(key)}, token, ttlArg(ttl)).Uint64()
+	return fence, fence > 0, err
+}
+
+// fenceKey counter key in the hash slot of key
+func fenceKey(key string) string {
+	if start := strings.Index(key, "{"); start >= 0 {
+		if end := strings.Index(key[start+1:], "}"); end > 0 {
+			return key + ":@fence"
+		}
+	}
+	return "{" + key + "}:@fence"
+}
+
+// rw lock hash: {token} => "r:{expire at}" | "w:{expire at}" (ms, redis time),
+// "__wait" => "p:{expire at}" when a writer waits for readers, new readers are refused until it expires
+const rwPrelude = `
+redis.replicate_commands()
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+-- prune expired holders, returns writer held, readers count, writer waiting
+local function prune(key)
+	local writer, readers, waiting = false, 0, false
+	local fields = redis.call("HGETALL", key)
+	for i = 1, #fields, 2 do
+		local mode, expireAt = string.match(fields[i + 1], "^(%a):(%d+)$")
+		if not expireAt or tonumber(expireAt) <= now then
+			redis.call("HDEL", key, fields[i])
+		elseif mode == "w" then
+			writer = true
+		elseif mode == "r" then
+			readers = readers + 1
+		elseif mode == "p" then
+			waiting = true
+		end
+	end
+	return writer, readers, waiting
+end
+
+local function expire(key, ttl)
+	if redis.call("PTTL", key) < ttl then
+		redis.call("PEXPIRE", key, ttl)
+	end
+end
+`
+
+var (
+	rLockScript = redis.NewScript(rwPrelude + `
+local writer, readers, waiting = prune(KEYS[1])
+if writer or waiting then
+	return 0
+end
+redis.call("HSET", KEYS[1], ARGV[1], "r:" .. (now + tonumber(ARGV[2])))
+expire(KEYS[1], tonumber(ARGV[2]))
+return 1
+`)
+
+	wLockScript = redis.NewScript(rwPrelude + `
+local writer, readers, waiting = prune(KEYS[1])
+if writer then
+	return 0
+end
+if readers > 0 then
+	redis.call("HSET", KEYS[1], "__wait", "p:" .. (now + tonumber(ARGV[3])))
+	expire(KEYS[1], tonumber(ARGV[3]))
+	return 0
+end
+redis.call("DEL", KEYS[1])
+redis.call("HSET", KEYS[1], ARGV[1], "w:" .. (now + tonumber(ARGV[2])))
+redis.call("PEXPIRE", KEYS[1], ARGV[2])
+return 1
+`)
+
+	rwUnlockScript = redis.NewScript(rwPrelude + `
+local value = redis.call("HGET", KEYS[1], ARGV[1])
+if not value then
+	return 0
+end
+local mode, expireAt = string.match(value, "^(%a):(%d+)$")
+redis.call("HDEL", KEYS[1], ARGV[1])
+if not expireAt or tonumber(expireAt) <= now then
+	return 0
+end
+if mode == "w" then
+	redis.call("DEL", KEYS[1])
+	return 1
+end
+prune(KEYS[1])
+return 1
+`)
+
+	rwRenewScript = redis.NewScript(rwPrelude + `
+local value = redis.call("HGET", KEYS[1], ARGV[1])
+if not value then
+	return 0
+end
+local mode, expireAt = string.match(value, "^(%a):(%d+)$")
+if not expireAt or tonumber(expireAt) <= now then
+	redis.call("HDEL", KEYS[1], ARGV[1])
+	return 0
+end
+redis.call("HSET", KEYS[1], ARGV[1], mode .. ":" .. (now + tonumber(ARGV[2])))
+prune(KEYS[1])
+expire(KEYS[1], tonumber(ARGV[2]))
+return 1
+`)
+)
+
+// rwWriterWait max time new readers are refused for a waiting writer
+const rwWriterWait = 5 * time.Second
+
+// semaphore zset: {token} => expire at (ms, redis time)
+var (
+	acquireScript = redis.NewScript(`
+redis.replicate_commands()
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now)
+if redis.call("ZCARD", KEYS[1]) >= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call("ZADD", KEYS[1], now + tonumber(ARGV[3]), ARGV[1])
+if redis.call("PTTL", KEYS[1]) < tonumber(ARGV[3]) then
+	redis.call("PEXPIRE", KEYS[1], ARGV[3])
+end
+return 1
+`)
+
+	semaphoreRenewScript = redis.NewScript(`
+redis.replicate_commands()
+if not redis.call("ZSCORE", KEYS[1], ARGV[1]) then
+	return 0
+end
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+redis.call("ZADD", KEYS[1], "XX", now + tonumber(ARGV[2]), ARGV[1])
+if redis.call("PTTL", KEYS[1]) < tonumber(ARGV[2]) then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 1
+`)
+)
+
+// reentrant lock hash: owner, count
+var (
+	reentrantLockScript = redis.NewScript(`
+local owner = redis.call("HGET", KEYS[1], "owner")
+if owner and owner ~= ARGV[1] then
+	return 0
+end
+redis.call("HSET", KEYS[1], "owner", ARGV[1])
+redis.call("HINCRBY", KEYS[1], "count", 1)
+redis.call("PEXPIRE", KEYS[1], ARGV[2])
+return 1
+`)
+
+	reentrantUnlockScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "owner") ~= ARGV[1] then
+	return 0
+end
+if redis.call("HINCRBY", KEYS[1], "count", -1) <= 0 then
+	redis.call("DEL", KEYS[1])
+end
+return 1
+`)
+
+	reentrantRenewScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "owner") ~= ARGV[1] then
+	return 0
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[2])
+return 1
+`)
+)
+
+func (r *RedisDriver) RLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	return r.runLock(ctx, rLockScript, key, token, ttlArg(ttl))
+}
+
+func (r *RedisDriver) WLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	wait := rwWriterWait
+	if ttl > 0 && ttl < wait {
+		wait = ttl
+	}
+	return r.runLock(ctx, wLockScript, key, token, ttlArg(ttl), wait.Milliseconds())
+}
+
+func (r *RedisDriver) RWUnlock(ctx context.Context, key string, token string) error {
+	return r.runOwned(ctx, rwUnlockScript, key, token)
+}
+
+func (r *RedisDriver) RWRenew(ctx context.Context, key string, token string, ttl time.Duration) error {
+	return r.runOwned(ctx, rwRenewScript, key, token, ttlArg(ttl))
+}
+
+func (r *RedisDriver) Acquire(ctx context.Context, key string, token string, limit uint, ttl time.Duration) (bool, error) {
+	return r.runLock(ctx, acquireScript, key, token, limit, ttlArg(ttl))
+}
+
+func (r *RedisDriver) Release(ctx context.Context, key string, token string) error {
+	n, err := r.client.ZRem(ctx, key, token).Result()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return lock.ErrNotOwner
+	}
+	return nil
 }
 
-func (r *RedisDriver) Unlock(ctx context.Context, key string) error {
-	return r.client.Del(ctx, key).Err()
+func (r *RedisDriver) SemaphoreRenew(ctx context.Context, key string, token string, ttl time.Duration) error {
+	return r.runOwned(ctx, semaphoreRenewScript, key, token, ttlArg(ttl))
+}
+
+func (r *RedisDriver) ReentrantLock(ctx context.Context, key string, owner string, ttl time.Duration) (bool, error) {
+	return r.runLock(ctx, reentrantLockScript, key, owner, ttlArg(ttl))
+}
+
+func (r *RedisDriver) ReentrantUnlock(ctx context.Context, key string, owner string) error {
+	return r.runOwned(ctx, reentrantUnlockScript, key, owner)
+}
+
+func (r *RedisDriver) ReentrantRenew(ctx context.Context, key string, owner string, ttl time.Duration) error {
+	return r.runOwned(ctx, reentrantRenewScript, key, owner, ttlArg(ttl))
+}
+
+// runLock script returns 1 acquired, 0 locked
+func (r *RedisDriver) runLock(ctx context.Context, script *redis.Script, key string, args ...any) (bool, error) {
+	n, err := script.Run(ctx, r.client, []string{key}, args...).Int()
+	return n == 1, err
+}
+
+// runOwned script returns 0 not owner
+func (r *RedisDriver) runOwned(ctx context.Context, script *redis.Script, key string, args ...any) error {
+	n, err := script.Run(ctx, r.client, []string{key}, args...).Int()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return lock.ErrNotOwner
+	}
+	return nil
+}
+
+// neverExpire ttl 0 of scripts (ms), about 35 years
+const neverExpire = 1 << 40
+
+// ttlArg ms
+func ttlArg(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return neverExpire
+	}
+	return ttl.Milliseconds()
 }
@@ -2,59 +2,145 @@ package lock
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"time"
 
+	"github.com/arklib/ark/metrics"
 	"github.com/arklib/ark/util"
 )
 
 var ErrKeyType = errors.New("key type error")
 var ErrIsLocked = errors.New("is locked")
 
+// ErrNotOwner unlock a lock expired or held by others
+var ErrNotOwner = errors.New("lock not owned")
+
+// ErrLeaseLost watchdog renew failed, cause of Payload.Context
+var ErrLeaseLost = errors.New("lock lease lost")
+
+var ErrRenewNotSupported = errors.New("lock driver not support renew")
+
+var ErrFenceNotSupported = errors.New("lock driver not support fencing")
+
+// ErrNotSupported driver not support the lock type
+var ErrNotSupported = errors.New("lock driver not support the lock type")
+
 type (
 	Driver interface {
-		Lock(ctx context.Context, key string, ttl time.Duration) (bool, error)
-		Unlock(ctx context.Context, key string) error
+		// Lock store the owner token
+		Lock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error)
+		// Unlock delete only if the token matches, otherwise ErrNotOwner
+		Unlock(ctx context.Context, key string, token string) error
+	}
+
+	// Renewer optional Driver method, required by Config.Watchdog
+	Renewer interface {
+		// Renew reset ttl only if the token matches, otherwise ErrNotOwner
+		Renew(ctx context.Context, key string, token string, ttl time.Duration) error
+	}
+
+	// Fencer optional Driver method, required by Config.UseFencing
+	Fencer interface {
+		// FenceLock Lock and issue an increasing fencing token of key in one atomic step
+		FenceLock(ctx context.Context, key string, token string, ttl time.Duration) (uint64, bool, error)
 	}
 
 	Config struct {
 		Driver Driver
 		Name   string
 		TTL    uint
+		// renew the lease at TTL/3 until Unlock or context done
+		Watchdog bool
+		// max holders of Semaphore
+		Limit uint
+		// increasing fencing token of each acquisition of a key, Lock only
+		UseFencing bool
 	}
 
 	Payload struct {
-		driver Driver
-		key    string
-		ctx    context.Context
+		key      string
+		token    string
+		fence    uint64
+		unlock   func(ctx context.Context, key string, token string) error
+		ctx      context.Context
+		leaseCtx context.Context
+		cancel   context.CancelCauseFunc
+	}
+
+	// lease driver methods of a lock type, renew and fenceLock nil are not supported
+	lease struct {
+		lock      func(ctx context.Context, key string, token string, ttl time.Duration) (bool, error)
+		fenceLock func(ctx context.Context, key string, token string, ttl time.Duration) (uint64, bool, error)
+		unlock    func(ctx context.Context, key string, token string) error
+		renew     func(ctx context.Context, key string, token string, ttl time.Duration) error
 	}
 
 	Lock struct {
-		driver Driver
-		name   string
-		ttl    time.Duration
+		driver     Driver
+		name       string
+		ttl        time.Duration
+		watchdog   bool
+		useFencing bool
 	}
+
+	fenceCtxKey struct{}
 )
 
 func Define(c Config) *Lock {
 	return &Lock{
-		driver: c.Driver,
-		name:   c.Name,
-		ttl:    time.Duration(c.TTL) * time.Second,
+		driver:     c.Driver,
+		name:       c.Name,
+		ttl:        time.Duration(c.TTL) * time.Second,
+		watchdog:   c.Watchdog,
+		useFencing: c.UseFencing,
 	}
 }
 
-func (l *Lock) Lock(ctx context.Context, key any) (payload *Payload, err error) {
+func (l *Lock) Lock(ctx context.Context, key any) (*Payload, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	ls := lease{lock: l.driver.Lock, unlock: l.driver.Unlock}
+	if renewer, ok := l.driver.(Renewer); ok {
+		ls.renew = renewer.Renew
+	}
+	if fencer, ok := l.driver.(Fencer); ok {
+		ls.fenceLock = fencer.FenceLock
+	}
+	return l.acquire(ctx, key, token, ls)
+}
+
+func (l *Lock) acquire(ctx context.Context, key any, token string, ls lease) (payload *Payload, err error) {
 	strKey := util.MakeStrKey(l.name, key)
 	if strKey == "" {
 		err = ErrKeyType
 		return
 	}
+	if l.watchdog && ls.renew == nil {
+		err = ErrRenewNotSupported
+		return
+	}
+
+	if l.useFencing && ls.fenceLock == nil {
+		err = ErrFenceNotSupported
+		return
+	}
 
-	lock, err := l.driver.Lock(ctx, strKey, l.ttl)
+	var lock bool
+	var fence uint64
+	if l.useFencing {
+		fence, lock, err = ls.fenceLock(ctx, strKey, token, l.ttl)
+	} else {
+		lock, err = ls.lock(ctx, strKey, token, l.ttl)
+	}
 	if err != nil {
 		return
 	}
+	metrics.ObserveLock(l.name, lock)
 	if !lock {
 		err = ErrIsLocked
 		return
@@ -62,12 +148,84 @@ func (l *Lock) Lock(ctx context.Context, key any) (payload *Payload, err error)
 
 	payload = &Payload{
 		ctx:    ctx,
-		driver: l.driver,
 		key:    strKey,
+		token:  token,
+		fence:  fence,
+		unlock: ls.unlock,
+	}
+
+	leaseCtx := ctx
+	if l.useFencing {
+		leaseCtx = context.WithValue(ctx, fenceCtxKey{}, fence)
+	}
+
+	payload.leaseCtx, payload.cancel = context.WithCancelCause(leaseCtx)
+	if l.watchdog && l.ttl > 0 {
+		go payload.watch(ls.renew, l.ttl)
 	}
 	return
 }
 
+// Token unique owner token of the acquisition
+func (p *Payload) Token() string {
+	return p.token
+}
+
+// Fence increasing fencing token, 0 without Config.UseFencing
+func (p *Payload) Fence() uint64 {
+	return p.fence
+}
+
+// Context done on Unlock, lock context done or lease lost (cause ErrLeaseLost)
+func (p *Payload) Context() context.Context {
+	return p.leaseCtx
+}
+
+// Lost closed when the lease is not held any more
+func (p *Payload) Lost() <-chan struct{} {
+	return p.leaseCtx.Done()
+}
+
 func (p *Payload) Unlock() error {
-	return p.driver.Unlock(p.ctx, p.key)
+	p.cancel(nil)
+	return p.unlock(context.WithoutCancel(p.ctx), p.key, p.token)
+}
+
+// watch renew at ttl/3, transient errors are retried until the lease expires
+func (p *Payload) watch(renew func(ctx context.Context, key string, token string, ttl time.Duration) error, ttl time.Duration) {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	renewedAt := time.Now()
+	for {
+		select {
+		case <-p.leaseCtx.Done():
+			return
+		case <-ticker.C:
+			err := renew(p.leaseCtx, p.key, p.token, ttl)
+			switch {
+			case err == nil:
+				renewedAt = time.Now()
+			case p.leaseCtx.Err() != nil:
+				return
+			case errors.Is(err, ErrNotOwner), time.Since(renewedAt) >= ttl:
+				p.cancel(errors.Join(ErrLeaseLost, err))
+				return
+			}
+		}
+	}
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// FenceFromContext fencing token of Payload.Context
+func FenceFromContext(ctx context.Context) (uint64, bool) {
+	fence, ok := ctx.Value(fenceCtxKey{}).(uint64)
+	return fence, ok
 }
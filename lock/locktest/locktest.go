@@ -0,0 +1,168 @@
+// Package locktest conformance checks of lock.Driver, like testing/fstest:
+//
+//	if err := locktest.TestDriver(driver.NewMemoryDriver(), time.Second); err != nil {
+//		t.Fatal(err)
+//	}
+package locktest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/arklib/ark/lock"
+)
+
+// TestDriver check Driver, and Renewer and Fencer if implemented,
+// ttl of expiry checks, etcd needs at least 2s (server min lease ttl)
+func TestDriver(driver lock.Driver, ttl time.Duration) error {
+	ctx := context.Background()
+	prefix := "locktest:" + randHex() + ":"
+
+	checks := []struct {
+		name  string
+		check func(ctx context.Context, driver lock.Driver, key string, ttl time.Duration) error
+	}{
+		{"lock", testLock},
+		{"unlock", testUnlock},
+		{"expire", testExpire},
+		{"renew", testRenew},
+		{"fence", testFence},
+	}
+
+	var errs []error
+	for _, c := range checks {
+		if err := c.check(ctx, driver, prefix+c.name, ttl); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func testLock(ctx context.Context, driver lock.Driver, key string, ttl time.Duration) error {
+	if err := expectLock(ctx, driver, key, "a", ttl, true); err != nil {
+		return err
+	}
+	if err := expectLock(ctx, driver, key, "b", ttl, false); err != nil {
+		return err
+	}
+	return driver.Unlock(ctx, key, "a")
+}
+
+func testUnlock(ctx context.Context, driver lock.Driver, key string, ttl time.Duration) error {
+	if err := driver.Unlock(ctx, key, "a"); !errors.Is(err, lock.ErrNotOwner) {
+		return fmt.Errorf("unlock not locked key: want ErrNotOwner, got %v", err)
+	}
+	if err := expectLock(ctx, driver, key, "a", ttl, true); err != nil {
+		return err
+	}
+	if err := driver.Unlock(ctx, key, "b"); !errors.Is(err, lock.ErrNotOwner) {
+		return fmt.Errorf("unlock with other token: want ErrNotOwner, got %v", err)
+	}
+	if err := driver.Unlock(ctx, key, "a"); err != nil {
+		return fmt.Errorf("unlock with owner token: %w", err)
+	}
+	return expectLock(ctx, driver, key, "b", ttl, true, driver.Unlock)
+}
+
+func testExpire(ctx context.Context, driver lock.Driver, key string, ttl time.Duration) error {
+	if err := expectLock(ctx, driver, key, "a", ttl, true); err != nil {
+		return err
+	}
+	time.Sleep(ttl * 5 / 2)
+
+	if err := expectLock(ctx, driver, key, "b", ttl, true); err != nil {
+		return fmt.Errorf("after ttl: %w", err)
+	}
+	if err := driver.Unlock(ctx, key, "a"); !errors.Is(err, lock.ErrNotOwner) {
+		return fmt.Errorf("unlock expired lock: want ErrNotOwner, got %v", err)
+	}
+	return driver.Unlock(ctx, key, "b")
+}
+
+func testRenew(ctx context.Context, driver lock.Driver, key string, ttl time.Duration) error {
+	renewer, ok := driver.(lock.Renewer)
+	if !ok {
+		return nil
+	}
+
+	if err := expectLock(ctx, driver, key, "a", ttl, true); err != nil {
+		return err
+	}
+	for i := 0; i < 3; i++ {
+		time.Sleep(ttl / 2)
+		if err := renewer.Renew(ctx, key, "a", ttl); err != nil {
+			return fmt.Errorf("renew owner token: %w", err)
+		}
+	}
+	if err := renewer.Renew(ctx, key, "b", ttl); !errors.Is(err, lock.ErrNotOwner) {
+		return fmt.Errorf("renew other token: want ErrNotOwner, got %v", err)
+	}
+	if err := expectLock(ctx, driver, key, "b", ttl, false); err != nil {
+		return fmt.Errorf("after renew: %w", err)
+	}
+
+	// renew with a longer ttl
+	if err := renewer.Renew(ctx, key, "a", ttl*2); err != nil {
+		return fmt.Errorf("renew longer ttl: %w", err)
+	}
+	time.Sleep(ttl * 3 / 2)
+	if err := expectLock(ctx, driver, key, "b", ttl, false); err != nil {
+		return fmt.Errorf("after renew longer ttl: %w", err)
+	}
+	return driver.Unlock(ctx, key, "a")
+}
+
+func testFence(ctx context.Context, driver lock.Driver, key string, ttl time.Duration) error {
+	fencer, ok := driver.(lock.Fencer)
+	if !ok {
+		return nil
+	}
+
+	var last uint64
+	for _, token := range []string{"a", "b", "c"} {
+		fence, ok, err := fencer.FenceLock(ctx, key, token, ttl)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("fence lock %s by %s: want true, got false", key, token)
+		}
+		if fence <= last {
+			return fmt.Errorf("fence not increasing: %d after %d", fence, last)
+		}
+		last = fence
+
+		if _, ok, err = fencer.FenceLock(ctx, key, "other", ttl); err != nil || ok {
+			return fmt.Errorf("fence lock locked key: want false, got %v %v", ok, err)
+		}
+		if err = driver.Unlock(ctx, key, token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expectLock lock with ttl, then optional unlock
+func expectLock(ctx context.Context, driver lock.Driver, key, token string, ttl time.Duration, want bool, unlock ...func(context.Context, string, string) error) error {
+	ok, err := driver.Lock(ctx, key, token, ttl)
+	if err != nil {
+		return err
+	}
+	if ok != want {
+		return fmt.Errorf("lock %s by %s: want %v, got %v", key, token, want, ok)
+	}
+	if ok && len(unlock) > 0 {
+		return unlock[0](ctx, key, token)
+	}
+	return nil
+}
+
+func randHex() string {
+	buf := make([]byte, 6)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
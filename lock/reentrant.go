@@ -0,0 +1,46 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrOwnerEmpty = errors.New("lock owner cannot be empty")
+
+type (
+	// ReentrantDriver optional Driver methods of ReentrantLock
+	ReentrantDriver interface {
+		// ReentrantLock acquire or increase count of the same owner
+		ReentrantLock(ctx context.Context, key string, owner string, ttl time.Duration) (bool, error)
+		// ReentrantUnlock decrease count, delete at zero, otherwise ErrNotOwner
+		ReentrantUnlock(ctx context.Context, key string, owner string) error
+		ReentrantRenew(ctx context.Context, key string, owner string, ttl time.Duration) error
+	}
+
+	// ReentrantLock lock again by the same owner, unlock as many times as locked
+	ReentrantLock struct {
+		lock *Lock
+	}
+)
+
+func DefineReentrant(c Config) *ReentrantLock {
+	return &ReentrantLock{lock: Define(c)}
+}
+
+// Lock owner like job id or worker id, Payload.Token is the owner
+func (l *ReentrantLock) Lock(ctx context.Context, key any, owner string) (*Payload, error) {
+	driver, ok := l.lock.driver.(ReentrantDriver)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	if owner == "" {
+		return nil, ErrOwnerEmpty
+	}
+
+	return l.lock.acquire(ctx, key, owner, lease{
+		lock:   driver.ReentrantLock,
+		unlock: driver.ReentrantUnlock,
+		renew:  driver.ReentrantRenew,
+	})
+}
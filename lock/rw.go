@@ -0,0 +1,63 @@
+package lock
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// RWDriver optional Driver methods of RWLock
+	RWDriver interface {
+		RLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error)
+		WLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error)
+		// RWUnlock unlock reader or writer, otherwise ErrNotOwner
+		RWUnlock(ctx context.Context, key string, token string) error
+		RWRenew(ctx context.Context, key string, token string, ttl time.Duration) error
+	}
+
+	// RWLock many readers or one writer, a waiting writer refuses new readers
+	RWLock struct {
+		lock *Lock
+	}
+)
+
+func DefineRW(c Config) *RWLock {
+	return &RWLock{lock: Define(c)}
+}
+
+// RLock shared with other readers, ErrIsLocked when write locked or a writer is waiting
+func (l *RWLock) RLock(ctx context.Context, key any) (*Payload, error) {
+	driver, ok := l.lock.driver.(RWDriver)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	return l.lock.acquire(ctx, key, token, lease{
+		lock:   driver.RLock,
+		unlock: driver.RWUnlock,
+		renew:  driver.RWRenew,
+	})
+}
+
+// Lock exclusive, ErrIsLocked when read or write locked,
+// new readers are refused for a while after a failed Lock, so writer retries are not starved by readers
+func (l *RWLock) Lock(ctx context.Context, key any) (*Payload, error) {
+	driver, ok := l.lock.driver.(RWDriver)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	return l.lock.acquire(ctx, key, token, lease{
+		lock:   driver.WLock,
+		unlock: driver.RWUnlock,
+		renew:  driver.RWRenew,
+	})
+}
@@ -0,0 +1,49 @@
+package lock
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// SemaphoreDriver optional Driver methods of Semaphore
+	SemaphoreDriver interface {
+		Acquire(ctx context.Context, key string, token string, limit uint, ttl time.Duration) (bool, error)
+		// Release otherwise ErrNotOwner
+		Release(ctx context.Context, key string, token string) error
+		SemaphoreRenew(ctx context.Context, key string, token string, ttl time.Duration) error
+	}
+
+	// Semaphore at most Config.Limit holders of a key
+	Semaphore struct {
+		lock  *Lock
+		limit uint
+	}
+)
+
+func DefineSemaphore(c Config) *Semaphore {
+	if c.Limit == 0 {
+		c.Limit = 1
+	}
+	return &Semaphore{lock: Define(c), limit: c.Limit}
+}
+
+// Acquire ErrIsLocked when holders reach the limit, Payload.Unlock releases
+func (s *Semaphore) Acquire(ctx context.Context, key any) (*Payload, error) {
+	driver, ok := s.lock.driver.(SemaphoreDriver)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	return s.lock.acquire(ctx, key, token, lease{
+		lock: func(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+			return driver.Acquire(ctx, key, token, s.limit, ttl)
+		},
+		unlock: driver.Release,
+		renew:  driver.SemaphoreRenew,
+	})
+}
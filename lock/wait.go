@@ -0,0 +1,89 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+type WaitOptions struct {
+	// first retry delay, default 50ms
+	MinDelay time.Duration
+	// max retry delay, default 1s
+	MaxDelay time.Duration
+	// delay multiplier of each retry, default 2
+	Multiplier float64
+	// random ratio (0-1) of delay, default 0.2
+	Jitter float64
+	// max wait without context deadline, 0 wait until context done
+	Timeout time.Duration
+}
+
+var defaultWaitOptions = WaitOptions{
+	MinDelay:   50 * time.Millisecond,
+	MaxDelay:   time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// LockWait retry with exponential backoff until acquired or context done (ErrIsLocked and context error)
+func (l *Lock) LockWait(ctx context.Context, key any, opts *WaitOptions) (*Payload, error) {
+	o := defaultWaitOptions
+	if opts != nil {
+		o.Timeout = opts.Timeout
+		if opts.MinDelay > 0 {
+			o.MinDelay = opts.MinDelay
+		}
+		if opts.MaxDelay > 0 {
+			o.MaxDelay = opts.MaxDelay
+		}
+		if opts.Multiplier >= 1 {
+			o.Multiplier = opts.Multiplier
+		}
+		if opts.Jitter > 0 {
+			o.Jitter = min(opts.Jitter, 1)
+		}
+	}
+
+	waitCtx := ctx
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+
+	delay := o.MinDelay
+	for {
+		payload, err := l.Lock(ctx, key)
+		if !errors.Is(err, ErrIsLocked) {
+			return payload, err
+		}
+
+		jitter := time.Duration((rand.Float64()*2 - 1) * o.Jitter * float64(delay))
+		timer := time.NewTimer(delay + jitter)
+		select {
+		case <-waitCtx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w: %w", ErrIsLocked, waitCtx.Err())
+		case <-timer.C:
+		}
+
+		delay = min(time.Duration(float64(delay)*o.Multiplier), o.MaxDelay)
+	}
+}
+
+// TryLockFn lock, run fn with the lease context, always unlock even on panic
+func (l *Lock) TryLockFn(ctx context.Context, key any, fn func(ctx context.Context) error) (err error) {
+	payload, err := l.Lock(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if unlockErr := payload.Unlock(); err == nil {
+			err = unlockErr
+		}
+	}()
+	return fn(payload.Context())
+}
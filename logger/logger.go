@@ -68,6 +68,8 @@ func NewConsole(c *Config) *Logger {
 	return &Logger{Logger: logger, Writer: logWriter}
 }
 
+var Levels = []string{"trace", "debug", "info", "notice", "warn", "error", "fatal"}
+
 func GetLogLevel(level string) hlog.Level {
 	switch level {
 	case "trace":
@@ -88,3 +90,11 @@ func GetLogLevel(level string) hlog.Level {
 		return hlog.LevelInfo
 	}
 }
+
+// Sync flush buffered log writer
+func (l *Logger) Sync() error {
+	if ws, ok := l.Writer.(zapcore.WriteSyncer); ok {
+		return ws.Sync()
+	}
+	return nil
+}
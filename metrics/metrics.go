@@ -0,0 +1,190 @@
+package metrics
+
+import (
+	"io"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/common/expfmt"
+)
+
+const namespace = "ark"
+
+var (
+	Registry = prometheus.NewRegistry()
+	enabled  atomic.Bool
+
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of http requests.",
+	}, []string{"method", "path", "code"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Http request latency in seconds.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "path"})
+
+	rpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rpc",
+		Name:      "requests_total",
+		Help:      "Total number of rpc requests.",
+	}, []string{"method", "code"})
+
+	rpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "rpc",
+		Name:      "request_duration_seconds",
+		Help:      "Rpc request latency in seconds.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	errorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "errors_total",
+		Help:      "Total number of errx errors by code.",
+	}, []string{"source", "code"})
+
+	queueMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "queue",
+		Name:      "messages_total",
+		Help:      "Total number of queue messages by action (produce, consume, error, retry, failed).",
+	}, []string{"topic", "task", "action"})
+
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Total number of cache reads by result (hit, miss, stale), stale reads are hits too.",
+	}, []string{"name", "result"})
+
+	cacheRefreshErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "refresh_errors_total",
+		Help:      "Total number of failed stale-while-revalidate background refreshes.",
+	}, []string{"name"})
+
+	lockRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "lock",
+		Name:      "requests_total",
+		Help:      "Total number of lock acquisitions by result (acquired, contended).",
+	}, []string{"name", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		rpcRequests,
+		rpcDuration,
+		errorsTotal,
+		queueMessages,
+		cacheRequests,
+		cacheRefreshErrors,
+		lockRequests,
+	)
+}
+
+// Enable start recording, helpers are no-op before
+func Enable() {
+	enabled.Store(true)
+}
+
+func IsEnabled() bool {
+	return enabled.Load()
+}
+
+// Write metrics in text exposition format
+func Write(w io.Writer) error {
+	mfs, err := Registry.Gather()
+	if err != nil {
+		return err
+	}
+	for _, mf := range mfs {
+		if _, err = expfmt.MetricFamilyToText(w, mf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ObserveHttp(method, path string, code int, elapsed time.Duration) {
+	if !IsEnabled() {
+		return
+	}
+	httpRequests.WithLabelValues(method, path, strconv.Itoa(code)).Inc()
+	httpDuration.WithLabelValues(method, path).Observe(elapsed.Seconds())
+}
+
+func ObserveRPC(method string, code int, elapsed time.Duration) {
+	if !IsEnabled() {
+		return
+	}
+	rpcRequests.WithLabelValues(method, strconv.Itoa(code)).Inc()
+	rpcDuration.WithLabelValues(method).Observe(elapsed.Seconds())
+}
+
+// ObserveError source: http | rpc
+func ObserveError(source string, code int) {
+	if !IsEnabled() {
+		return
+	}
+	errorsTotal.WithLabelValues(source, strconv.Itoa(code)).Inc()
+}
+
+// ObserveQueue action: produce | consume | error | retry | failed
+func ObserveQueue(topic, task, action string) {
+	if !IsEnabled() {
+		return
+	}
+	queueMessages.WithLabelValues(topic, task, action).Inc()
+}
+
+func ObserveCache(name string, hit bool) {
+	if !IsEnabled() {
+		return
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheRequests.WithLabelValues(name, result).Inc()
+}
+
+func ObserveCacheStale(name string) {
+	if !IsEnabled() {
+		return
+	}
+	cacheRequests.WithLabelValues(name, "stale").Inc()
+}
+
+func ObserveCacheRefreshError(name string) {
+	if !IsEnabled() {
+		return
+	}
+	cacheRefreshErrors.WithLabelValues(name).Inc()
+}
+
+func ObserveLock(name string, acquired bool) {
+	if !IsEnabled() {
+		return
+	}
+	result := "contended"
+	if acquired {
+		result = "acquired"
+	}
+	lockRequests.WithLabelValues(name, result).Inc()
+}
@@ -0,0 +1,48 @@
+package driver
+
+import (
+	"context"
+	"sync"
+)
+
+type (
+	// delayMovers one mover of a topic while any consumer of the topic runs
+	delayMovers struct {
+		mu     sync.Mutex
+		movers map[string]*delayMover
+	}
+
+	delayMover struct {
+		consumers int
+		cancel    context.CancelFunc
+	}
+)
+
+// start run the mover of topic if not running, the returned release stops it after the last consumer
+func (d *delayMovers) start(ctx context.Context, topic string, run func(ctx context.Context, topic string)) (release func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.movers == nil {
+		d.movers = make(map[string]*delayMover)
+	}
+	mover, ok := d.movers[topic]
+	if !ok {
+		moverCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		mover = &delayMover{cancel: cancel}
+		d.movers[topic] = mover
+		go run(moverCtx, topic)
+	}
+	mover.consumers++
+
+	return func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		mover.consumers--
+		if mover.consumers == 0 {
+			mover.cancel()
+			delete(d.movers, topic)
+		}
+	}
+}
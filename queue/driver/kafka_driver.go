@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
 	"time"
 
 	"github.com/segmentio/kafka-go"
@@ -11,10 +12,26 @@ import (
 	"github.com/arklib/ark/queue"
 )
 
+// kafkaScheduledAtHeader scheduled time (unix milli) of delay topic messages
+const kafkaScheduledAtHeader = "scheduled-at"
+
+// kafkaDelayLevels delay topics "{topic}.delay.{level}", a message waits at most its level in a delay topic
+var kafkaDelayLevels = []struct {
+	name  string
+	delay time.Duration
+}{
+	{"1s", time.Second},
+	{"10s", 10 * time.Second},
+	{"1m", time.Minute},
+	{"10m", 10 * time.Minute},
+	{"1h", time.Hour},
+}
+
 type KafkaDriver struct {
 	queue.Driver
 	brokers []string
 	Writer  *kafka.Writer
+	movers  delayMovers
 }
 
 func NewKafkaDriver(brokers ...string) *KafkaDriver {
@@ -30,6 +47,17 @@ func NewKafkaDriver(brokers ...string) *KafkaDriver {
 	}
 }
 
+func (k *KafkaDriver) Ping(ctx context.Context) (err error) {
+	var conn *kafka.Conn
+	for _, broker := range k.brokers {
+		conn, err = kafka.DialContext(ctx, "tcp", broker)
+		if err == nil {
+			return conn.Close()
+		}
+	}
+	return
+}
+
 func (k *KafkaDriver) Produce(ctx context.Context, topic string, rawMessage []byte) error {
 	message := kafka.Message{
 		Topic: topic,
@@ -38,7 +66,107 @@ func (k *KafkaDriver) Produce(ctx context.Context, topic string, rawMessage []by
 	return k.Writer.WriteMessages(ctx, message)
 }
 
+// ProduceAt write to the delay topic of the largest level not after the time (or the first level),
+// moved to the next level or the topic by consumers when the level delay passes.
+// messages of a delay topic wait the same delay, so earlier messages never block later due ones.
+func (k *KafkaDriver) ProduceAt(ctx context.Context, topic string, rawMessage []byte, at time.Time) error {
+	delay := time.Until(at)
+	level := kafkaDelayLevels[0].name
+	for _, l := range kafkaDelayLevels {
+		if l.delay <= delay {
+			level = l.name
+		}
+	}
+
+	message := kafka.Message{
+		Topic: topic + ".delay." + level,
+		Value: rawMessage,
+		Headers: []kafka.Header{
+			{Key: kafkaScheduledAtHeader, Value: []byte(strconv.FormatInt(at.UnixMilli(), 10))},
+		},
+		Time: time.Now(),
+	}
+	return k.Writer.WriteMessages(ctx, message)
+}
+
+// runDelayMovers movers of all delay levels of the topic
+func (k *KafkaDriver) runDelayMovers(ctx context.Context, topic string) {
+	for _, level := range kafkaDelayLevels {
+		go k.runDelayMover(ctx, topic, level.name, level.delay)
+	}
+}
+
+// runDelayMover mover of a delay level by the consumer group "{topic}.delay.{level}"
+func (k *KafkaDriver) runDelayMover(ctx context.Context, topic, level string, delay time.Duration) {
+	delayTopic := topic + ".delay." + level
+	r := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: k.brokers,
+		GroupID: delayTopic,
+		Topic:   delayTopic,
+	})
+	defer r.Close()
+
+	for {
+		m, err := r.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("[kafka.delay] topic: %s, error: %v\n", delayTopic, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		var at time.Time
+		for _, header := range m.Headers {
+			if header.Key == kafkaScheduledAtHeader {
+				ms, _ := strconv.ParseInt(string(header.Value), 10, 64)
+				at = time.UnixMilli(ms)
+			}
+		}
+
+		// wait until due or the level delay passes
+		wait := time.Until(at)
+		if levelWait := time.Until(m.Time.Add(delay)); levelWait < wait {
+			wait = levelWait
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		for {
+			if time.Now().Before(at) {
+				err = k.ProduceAt(ctx, topic, m.Value, at)
+			} else {
+				err = k.Produce(ctx, topic, m.Value)
+			}
+			if err == nil {
+				break
+			}
+			log.Printf("[kafka.delay] topic: %s, move error: %v\n", delayTopic, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+		}
+
+		err = r.CommitMessages(ctx, m)
+		if err != nil {
+			log.Printf("[kafka.delay] topic: %s, commit error: %v\n", delayTopic, err)
+		}
+	}
+}
+
 func (k *KafkaDriver) Consume(ctx context.Context, topic, group string, handler queue.ConsumeTaskHandler) error {
+	defer k.movers.start(ctx, topic, k.runDelayMovers)()
+
 	r := kafka.NewReader(kafka.ReaderConfig{
 		Brokers: k.brokers,
 		GroupID: group,
@@ -47,12 +175,20 @@ func (k *KafkaDriver) Consume(ctx context.Context, topic, group string, handler
 		// MaxBytes:       10e6,        // 10MB
 		// CommitInterval: time.Second, // flushes commits to Kafka every second
 	})
+	defer r.Close()
 
 	for {
 		m, err := r.FetchMessage(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
 			fmt.Printf("[kafka.fetch] topic: %s, group: %s, error: %v\n", topic, group, err)
-			time.Sleep(time.Second)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+			}
 			continue
 		}
 
@@ -65,7 +201,11 @@ func (k *KafkaDriver) Consume(ctx context.Context, topic, group string, handler
 		err = r.CommitMessages(ctx, m)
 		if err != nil {
 			log.Printf("[kafka.commit] topic: %s, group: %s, key: %s, error: %v\n", topic, group, m.Key, err)
-			time.Sleep(time.Second)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+			}
 			continue
 		}
 	}
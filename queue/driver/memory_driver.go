@@ -0,0 +1,105 @@
+package driver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/arklib/ark/queue"
+)
+
+type (
+	// MemoryDriver in-process topics for tests, messages are kept,
+	// every group receives all messages, consumers of the same group share them.
+	MemoryDriver struct {
+		queue.Driver
+		mu     sync.Mutex
+		topics map[string]*memoryTopic
+	}
+
+	memoryTopic struct {
+		messages [][]byte
+		groups   map[string]int
+		// closed on produce
+		notify chan struct{}
+	}
+)
+
+func NewMemoryDriver() *MemoryDriver {
+	return &MemoryDriver{topics: make(map[string]*memoryTopic)}
+}
+
+func (m *MemoryDriver) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (m *MemoryDriver) Produce(ctx context.Context, topic string, rawMessage []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.getTopic(topic)
+	t.messages = append(t.messages, append([]byte(nil), rawMessage...))
+	close(t.notify)
+	t.notify = make(chan struct{})
+	return nil
+}
+
+func (m *MemoryDriver) ProduceAt(ctx context.Context, topic string, rawMessage []byte, at time.Time) error {
+	rawMessage = append([]byte(nil), rawMessage...)
+	time.AfterFunc(time.Until(at), func() {
+		_ = m.Produce(context.Background(), topic, rawMessage)
+	})
+	return nil
+}
+
+// Consume new group starts from the first message, failed messages are not redelivered
+func (m *MemoryDriver) Consume(ctx context.Context, topic, group string, handler queue.ConsumeTaskHandler) error {
+	for {
+		rawMessage, notify := m.next(topic, group)
+		if rawMessage == nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-notify:
+			}
+			continue
+		}
+		_ = handler(rawMessage)
+	}
+}
+
+// Len messages not consumed by the group
+func (m *MemoryDriver) Len(topic, group string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.getTopic(topic)
+	return len(t.messages) - t.groups[group]
+}
+
+// next claim the next message of the group, or returns the notify channel
+func (m *MemoryDriver) next(topic, group string) ([]byte, <-chan struct{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.getTopic(topic)
+	offset := t.groups[group]
+	if offset >= len(t.messages) {
+		return nil, t.notify
+	}
+
+	t.groups[group] = offset + 1
+	return t.messages[offset], nil
+}
+
+func (m *MemoryDriver) getTopic(topic string) *memoryTopic {
+	t, ok := m.topics[topic]
+	if !ok {
+		t = &memoryTopic{
+			groups: make(map[string]int),
+			notify: make(chan struct{}),
+		}
+		m.topics[topic] = t
+	}
+	return t
+}
@@ -2,8 +2,11 @@ package driver
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -12,11 +15,33 @@ import (
 	"github.com/arklib/ark/queue"
 )
 
+// delayMoveScript move due messages (member: {id}:{message}) of the zset to the stream,
+// trimmed by ARGV[3] (MAXLEN | MINID) ARGV[4] like Produce
+var delayMoveScript = redis.NewScript(`
+local members = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+for _, member in ipairs(members) do
+	local i = string.find(member, ":", 1, true)
+	if ARGV[3] ~= "" then
+		redis.call("XADD", KEYS[2], ARGV[3], "~", ARGV[4], "*", "message", string.sub(member, i + 1))
+	else
+		redis.call("XADD", KEYS[2], "*", "message", string.sub(member, i + 1))
+	end
+	redis.call("ZREM", KEYS[1], member)
+end
+return #members
+`)
+
+const (
+	delayMoveBatch    = 100
+	delayMoveInterval = time.Second
+)
+
 type RedisDriver struct {
 	queue.Driver
 	ttl    int64
 	maxLen int64
 	client redis.Cmdable
+	movers delayMovers
 }
 
 func NewRedisDriver(client redis.Cmdable) *RedisDriver {
@@ -41,6 +66,10 @@ func (r *RedisDriver) WithMaxLen(len int64) *RedisDriver {
 	return r
 }
 
+func (r *RedisDriver) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
 func (r *RedisDriver) Produce(ctx context.Context, topic string, message []byte) error {
 	args := &redis.XAddArgs{
 		Stream: topic,
@@ -49,17 +78,83 @@ func (r *RedisDriver) Produce(ctx context.Context, topic string, message []byte)
 	}
 
 	// auto clear message
+	switch strategy, threshold := r.trim(); strategy {
+	case "MAXLEN":
+		args.MaxLen = r.maxLen
+	case "MINID":
+		args.MinID = threshold
+	}
+	return r.client.XAdd(ctx, args).Err()
+}
+
+// trim strategy and threshold of XADD, empty strategy is not trimmed
+func (r *RedisDriver) trim() (string, string) {
 	switch {
 	case r.maxLen > 0:
-		args.MaxLen = r.maxLen
+		return "MAXLEN", cast.ToString(r.maxLen)
 	case r.ttl > 0:
 		expired := time.Now().Unix() - r.ttl
-		args.MinID = fmt.Sprintf("%s-0", cast.ToString(expired*1000))
+		return "MINID", fmt.Sprintf("%s-0", cast.ToString(expired*1000))
+	}
+	return "", ""
+}
+
+// ProduceAt add to the delay zset "{topic}:delay", moved to the stream by consumers
+func (r *RedisDriver) ProduceAt(ctx context.Context, topic string, message []byte, at time.Time) error {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+
+	member := hex.EncodeToString(id) + ":" + string(message)
+	return r.client.ZAdd(ctx, delayKey(topic), redis.Z{
+		Score:  float64(at.UnixMilli()),
+		Member: member,
+	}).Err()
+}
+
+// MoveDelayed move due messages to the stream, returns moved count
+func (r *RedisDriver) MoveDelayed(ctx context.Context, topic string) (int, error) {
+	now := time.Now().UnixMilli()
+	keys := []string{delayKey(topic), topic}
+	strategy, threshold := r.trim()
+	return delayMoveScript.Run(ctx, r.client, keys, now, delayMoveBatch, strategy, threshold).Int()
+}
+
+// delayKey zset key in the hash slot of the topic stream
+func delayKey(topic string) string {
+	if start := strings.Index(topic, "{"); start >= 0 {
+		if end := strings.Index(topic[start+1:], "}"); end > 0 {
+			return topic + ":delay"
+		}
+	}
+	return "{" + topic + "}:delay"
+}
+
+func (r *RedisDriver) runDelayMover(ctx context.Context, topic string) {
+	ticker := time.NewTicker(delayMoveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for {
+			n, err := r.MoveDelayed(ctx, topic)
+			if err != nil {
+				log.Printf("[redis.delay] topic: %s, error: %v\n", topic, err)
+				break
+			}
+			if n < delayMoveBatch {
+				break
+			}
+		}
 	}
-	return r.client.XAdd(ctx, args).Err()
 }
 
 func (r *RedisDriver) Consume(ctx context.Context, topic, group string, handler queue.ConsumeTaskHandler) error {
+	defer r.movers.start(ctx, topic, r.runDelayMover)()
+
 	err := r.initConsume(ctx, topic, group)
 	if err != nil {
 		return err
@@ -73,9 +168,16 @@ func (r *RedisDriver) Consume(ctx context.Context, topic, group string, handler
 	}
 	for {
 		streams, err := r.client.XReadGroup(ctx, args).Result()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
 			fmt.Printf("[redis.xRead] topic: %s, group: %s, error: %v\n", topic, group, err)
-			time.Sleep(time.Second)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+			}
 			continue
 		}
 
@@ -114,8 +216,11 @@ func (r *RedisDriver) initConsume(ctx context.Context, topic, groupName string)
 		}
 
 		// wait first message
-		time.Sleep(time.Second)
-		continue
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
 	}
 
 	groups, err := r.client.XInfoGroups(ctx, topic).Result()
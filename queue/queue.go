@@ -1,13 +1,20 @@
 package queue
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
+	"github.com/arklib/ark/metrics"
 	"github.com/arklib/ark/serializer"
 )
 
+var ErrDelayNotSupported = errors.New("queue driver not support delay")
+
 type (
 	ConsumeTaskHandler func(rawMessage []byte) error
 	Driver             interface {
@@ -15,6 +22,12 @@ type (
 		Consume(ctx context.Context, topic, group string, handler ConsumeTaskHandler) error
 	}
 
+	// DelayDriver optional Driver method of PushAt & PushDelay
+	DelayDriver interface {
+		// ProduceAt deliver to topic at the time
+		ProduceAt(ctx context.Context, topic string, rawMessage []byte, at time.Time) error
+	}
+
 	RetryPush   func(id string, rawMessage []byte) error
 	RetryDriver interface {
 		Init(topic, group string) error
@@ -26,14 +39,30 @@ type (
 		Name  string
 		Run   func() error
 		Retry func() error
+		// RunContext consume until ctx done
+		RunContext func(ctx context.Context) error
 	}
 
 	Message struct {
 		Task       string `json:"task"`
 		Data       any    `json:"data"`
 		RetryCount uint   `json:"retryCount"`
+		// scheduled time (unix milli) of delayed messages
+		ScheduledAt int64 `json:"scheduledAt,omitempty"`
 	}
 
+	// messageEnvelope json wire format of Message, only Data is encoded by the queue Serializer,
+	// json data is inlined like before, other data (like protobuf) is kept in DataBytes
+	messageEnvelope struct {
+		Task        string          `json:"task"`
+		Data        json.RawMessage `json:"data,omitempty"`
+		DataBytes   []byte          `json:"dataBytes,omitempty"`
+		RetryCount  uint            `json:"retryCount"`
+		ScheduledAt int64           `json:"scheduledAt,omitempty"`
+	}
+
+	messageCtxKey struct{}
+
 	TaskConfig struct {
 		MaxRetry      uint
 		RetryInterval uint
@@ -84,11 +113,84 @@ func (q *Queue[Data]) Push(ctx context.Context, data *Data) error {
 	message := &Message{
 		Data: data,
 	}
-	rawMessage, err := q.Serializer.Encode(message)
+	rawMessage, err := q.encodeMessage(message)
+	if err != nil {
+		return err
+	}
+
+	err = q.Driver.Produce(ctx, q.Name, rawMessage)
+	if err == nil {
+		metrics.ObserveQueue(q.Name, "", "produce")
+	}
+	return err
+}
+
+func (q *Queue[Data]) PushDelay(ctx context.Context, data *Data, delay time.Duration) error {
+	return q.PushAt(ctx, data, time.Now().Add(delay))
+}
+
+// PushAt deliver at the time, past time is delivered right away
+func (q *Queue[Data]) PushAt(ctx context.Context, data *Data, at time.Time) error {
+	driver, ok := q.Driver.(DelayDriver)
+	if !ok {
+		return ErrDelayNotSupported
+	}
+
+	message := &Message{
+		Data:        data,
+		ScheduledAt: at.UnixMilli(),
+	}
+	rawMessage, err := q.encodeMessage(message)
+	if err != nil {
+		return err
+	}
+
+	if at.After(time.Now()) {
+		err = driver.ProduceAt(ctx, q.Name, rawMessage, at)
+	} else {
+		err = q.Driver.Produce(ctx, q.Name, rawMessage)
+	}
+	if err == nil {
+		metrics.ObserveQueue(q.Name, "", "produce")
+	}
+	return err
+}
+
+func (q *Queue[Data]) encodeMessage(message *Message) ([]byte, error) {
+	data, err := q.Serializer.Encode(message.Data)
 	if err != nil {
+		return nil, err
+	}
+
+	envelope := &messageEnvelope{
+		Task:        message.Task,
+		RetryCount:  message.RetryCount,
+		ScheduledAt: message.ScheduledAt,
+	}
+	// inline only json kept byte for byte, json.Marshal compacts & escapes raw json
+	if raw, err := json.Marshal(json.RawMessage(data)); err == nil && bytes.Equal(raw, data) {
+		envelope.Data = data
+	} else {
+		envelope.DataBytes = data
+	}
+	return json.Marshal(envelope)
+}
+
+func (q *Queue[Data]) decodeMessage(rawMessage []byte, message *Message) error {
+	envelope := new(messageEnvelope)
+	if err := json.Unmarshal(rawMessage, envelope); err != nil {
 		return err
 	}
-	return q.Driver.Produce(ctx, q.Name, rawMessage)
+
+	message.Task = envelope.Task
+	message.RetryCount = envelope.RetryCount
+	message.ScheduledAt = envelope.ScheduledAt
+
+	data := []byte(envelope.Data)
+	if envelope.DataBytes != nil {
+		data = envelope.DataBytes
+	}
+	return q.Serializer.Decode(data, message.Data)
 }
 
 func (q *Queue[Data]) AddTask(name string, handler TaskHandler[Data], c TaskConfig) *Queue[Data] {
@@ -106,6 +208,11 @@ func (q *Queue[Data]) AddTask(name string, handler TaskHandler[Data], c TaskConf
 }
 
 func (q *Queue[Data]) RunTask(name string) error {
+	return q.RunTaskContext(context.Background(), name)
+}
+
+// RunTaskContext consume until ctx done, delay movers of the driver stop with it
+func (q *Queue[Data]) RunTaskContext(ctx context.Context, name string) error {
 	task, ok := q.Tasks[name]
 	if !ok {
 		err := fmt.Errorf("[queue.task] topic: %s, task: %s, undefined\n", q.Name, name)
@@ -116,11 +223,10 @@ func (q *Queue[Data]) RunTask(name string) error {
 		return err
 	}
 
-	ctx := context.Background()
 	err := q.Driver.Consume(ctx, q.Name, task.Name, func(rawMessage []byte) error {
 		return q.handleTask(ctx, task, rawMessage)
 	})
-	if err != nil {
+	if err != nil && ctx.Err() == nil {
 		err = fmt.Errorf("[queue.task] consume, topic: %s, task: %s, error: %s\n", q.Name, name, err)
 		return err
 	}
@@ -132,7 +238,7 @@ func (q *Queue[Data]) handleTask(ctx context.Context, task *Task[Data], rawMessa
 	message := &Message{Data: data}
 
 	// decode
-	err := q.Serializer.Decode(rawMessage, message)
+	err := q.decodeMessage(rawMessage, message)
 	if err != nil {
 		return q.handleTaskError(task, message, err.Error())
 	}
@@ -143,10 +249,12 @@ func (q *Queue[Data]) handleTask(ctx context.Context, task *Task[Data], rawMessa
 	}
 
 	// handle task
-	err = task.Handler(ctx, data)
+	err = task.Handler(context.WithValue(ctx, messageCtxKey{}, message), data)
 	if err != nil {
+		metrics.ObserveQueue(q.Name, task.Name, "error")
 		return q.handleTaskError(task, message, err.Error())
 	}
+	metrics.ObserveQueue(q.Name, task.Name, "consume")
 	return nil
 }
 
@@ -156,9 +264,10 @@ func (q *Queue[Data]) handleTaskError(task *Task[Data], message *Message, errMes
 	isFailed := task.MaxRetry > 0 && message.RetryCount > task.MaxRetry
 	if isFailed {
 		message.RetryCount -= 1
+		metrics.ObserveQueue(q.Name, task.Name, "failed")
 	}
 
-	rawMessage, err := q.Serializer.Encode(message)
+	rawMessage, err := q.encodeMessage(message)
 	if err != nil {
 		log.Printf("[queue.task] encode, topic: %s, task: %s, error: %s\n", q.Name, task.Name, err)
 		return err
@@ -180,6 +289,7 @@ func (q *Queue[Data]) RunTaskRetry(name string) error {
 	ctx := context.Background()
 	push := func(id string, rawMessage []byte) error {
 		log.Printf("[queue.task] task.retry, id: %s, topic: %s, task: %s\n", id, q.Name, name)
+		metrics.ObserveQueue(q.Name, name, "retry")
 		return q.Driver.Produce(ctx, q.Name, rawMessage)
 	}
 	return q.RetryDriver.Run(q.Name, name, push)
@@ -196,8 +306,17 @@ func (q *Queue[Data]) GetCmdTasks() []*CmdTask {
 			Retry: func() error {
 				return q.RunTaskRetry(task.Name)
 			},
+			RunContext: func(ctx context.Context) error {
+				return q.RunTaskContext(ctx, task.Name)
+			},
 		}
 		cmdTasks = append(cmdTasks, cmdTask)
 	}
 	return cmdTasks
 }
+
+// MessageFromContext message of the task handler context, like ScheduledAt & RetryCount
+func MessageFromContext(ctx context.Context) (*Message, bool) {
+	message, ok := ctx.Value(messageCtxKey{}).(*Message)
+	return message, ok
+}
@@ -0,0 +1,183 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/arklib/ark/queue"
+	"github.com/arklib/ark/queue/driver"
+	"github.com/arklib/ark/queue/retry"
+	"github.com/arklib/ark/serializer"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type testData struct {
+	Id int `json:"id"`
+}
+
+func TestRunTaskRetry(t *testing.T) {
+	retryDriver := retry.NewMemoryRetryDriver(retry.MemoryRetryConfig{IntervalUnit: time.Millisecond})
+	q := queue.Define[testData](queue.Config{
+		Name:        "test",
+		Driver:      driver.NewMemoryDriver(),
+		RetryDriver: retryDriver,
+	})
+
+	var mu sync.Mutex
+	var calls []int
+	called := make(chan struct{}, 2)
+	q.AddTask("handle", func(ctx context.Context, data *testData) error {
+		mu.Lock()
+		calls = append(calls, data.Id)
+		n := len(calls)
+		mu.Unlock()
+
+		called <- struct{}{}
+		if n == 1 {
+			return errors.New("fail once")
+		}
+		return nil
+	}, queue.TaskConfig{MaxRetry: 3, RetryInterval: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- q.RunTaskContext(ctx, "handle") }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("run task: %s", err)
+		}
+	}()
+
+	if err := q.Push(ctx, &testData{Id: 1}); err != nil {
+		t.Fatal(err)
+	}
+	waitCalled(t, called)
+
+	// retry item is added after the handler returns
+	var items []*retry.MemoryRetryItem
+	for i := 0; i < 100 && len(items) == 0; i++ {
+		time.Sleep(5 * time.Millisecond)
+		items = retryDriver.List("test", "handle")
+	}
+	if len(items) != 1 || items[0].Error != "fail once" {
+		t.Fatalf("retry items: want 1 with error, got %+v", items)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if err := q.RunTaskRetry("handle"); err != nil {
+		t.Fatal(err)
+	}
+	waitCalled(t, called)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) != 2 || calls[1] != 1 {
+		t.Fatalf("handler calls: want [1 1], got %v", calls)
+	}
+	if items = retryDriver.List("test", "handle"); len(items) != 0 {
+		t.Fatalf("retry items after redelivery: want 0, got %d", len(items))
+	}
+}
+
+func TestMessageSerializer(t *testing.T) {
+	tests := []struct {
+		name       string
+		serializer serializer.Serializer
+	}{
+		{"gojson", serializer.NewGoJson()},
+		{"msgpack", serializer.NewMsgPack()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := consumeOne(t, tt.serializer, &testData{Id: 1})
+			if got.Id != 1 {
+				t.Fatalf("data: want id 1, got %+v", got)
+			}
+		})
+	}
+
+	t.Run("protobuf", func(t *testing.T) {
+		got := consumeOne(t, serializer.NewProtobuf(), wrapperspb.String("ark"))
+		if got.GetValue() != "ark" {
+			t.Fatalf("data: want ark, got %q", got.GetValue())
+		}
+	})
+}
+
+// messages encoded as a whole by the json serializer before data envelopes
+func TestDecodeJsonMessage(t *testing.T) {
+	d := driver.NewMemoryDriver()
+	q := queue.Define[testData](queue.Config{
+		Name:        "test",
+		Driver:      d,
+		RetryDriver: retry.NewMemoryRetryDriver(retry.MemoryRetryConfig{}),
+	})
+
+	called := make(chan *testData, 1)
+	q.AddTask("handle", func(ctx context.Context, data *testData) error {
+		called <- data
+		return nil
+	}, queue.TaskConfig{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = q.RunTaskContext(ctx, "handle") }()
+
+	err := d.Produce(ctx, "test", []byte(`{"task":"","data":{"id":7},"retryCount":0}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case data := <-called:
+		if data.Id != 7 {
+			t.Fatalf("data: want id 7, got %+v", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler not called")
+	}
+}
+
+// consumeOne push data to a new queue and return the consumed data
+func consumeOne[Data any](t *testing.T, s serializer.Serializer, data *Data) *Data {
+	t.Helper()
+	q := queue.Define[Data](queue.Config{
+		Name:        "test",
+		Driver:      driver.NewMemoryDriver(),
+		RetryDriver: retry.NewMemoryRetryDriver(retry.MemoryRetryConfig{}),
+		Serializer:  s,
+	})
+
+	called := make(chan *Data, 1)
+	q.AddTask("handle", func(ctx context.Context, data *Data) error {
+		called <- data
+		return nil
+	}, queue.TaskConfig{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = q.RunTaskContext(ctx, "handle") }()
+
+	if err := q.Push(ctx, data); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-called:
+		return got
+	case <-time.After(time.Second):
+		t.Fatal("handler not called")
+		return nil
+	}
+}
+
+func waitCalled(t *testing.T, called <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatal("handler not called")
+	}
+}
@@ -0,0 +1,116 @@
+package retry
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/arklib/ark/queue"
+)
+
+type (
+	MemoryRetryConfig struct {
+		// unit of task retry interval, default time.Second, tests may shorten it
+		IntervalUnit time.Duration
+	}
+
+	MemoryRetryDriver struct {
+		queue.RetryDriver
+		config MemoryRetryConfig
+		mu     sync.Mutex
+		lastId uint
+		items  []*MemoryRetryItem
+	}
+
+	MemoryRetryItem struct {
+		ID       uint
+		Topic    string
+		Task     string
+		IsFailed bool
+		Interval uint
+		Message  []byte
+		Error    string
+		NextAt   time.Time
+	}
+)
+
+func NewMemoryRetryDriver(config MemoryRetryConfig) *MemoryRetryDriver {
+	if config.IntervalUnit <= 0 {
+		config.IntervalUnit = time.Second
+	}
+	return &MemoryRetryDriver{config: config}
+}
+
+func (r *MemoryRetryDriver) Init(topic, task string) error {
+	return nil
+}
+
+func (r *MemoryRetryDriver) Add(topic, task string, rawMessage []byte, errMessage string, interval uint, isFailed bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastId++
+	r.items = append(r.items, &MemoryRetryItem{
+		ID:       r.lastId,
+		Topic:    topic,
+		Task:     task,
+		IsFailed: isFailed,
+		Interval: interval,
+		Message:  append([]byte(nil), rawMessage...),
+		Error:    errMessage,
+		NextAt:   time.Now().Add(time.Duration(interval) * r.config.IntervalUnit),
+	})
+	return nil
+}
+
+// Run push due items once, like DBRetryDriver
+func (r *MemoryRetryDriver) Run(topic string, task string, push queue.RetryPush) error {
+	for _, item := range r.due(topic, task) {
+		err := push(strconv.Itoa(int(item.ID)), item.Message)
+		if err != nil {
+			return err
+		}
+		r.remove(item.ID)
+	}
+	return nil
+}
+
+// List items of the topic task, include failed
+func (r *MemoryRetryDriver) List(topic, task string) []*MemoryRetryItem {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var items []*MemoryRetryItem
+	for _, item := range r.items {
+		if item.Topic == topic && item.Task == task {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (r *MemoryRetryDriver) due(topic, task string) []*MemoryRetryItem {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var items []*MemoryRetryItem
+	for _, item := range r.items {
+		if item.Topic == topic && item.Task == task && !item.IsFailed && !item.NextAt.After(now) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (r *MemoryRetryDriver) remove(id uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, item := range r.items {
+		if item.ID == id {
+			r.items = append(r.items[:i], r.items[i+1:]...)
+			return
+		}
+	}
+}
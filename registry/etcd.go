@@ -0,0 +1,16 @@
+package registry
+
+import (
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func NewEtcdClient(c *Config) (*clientv3.Client, error) {
+	return clientv3.New(clientv3.Config{
+		Endpoints:   c.Addrs,
+		DialTimeout: 5 * time.Second,
+		Username:    c.Username,
+		Password:    c.Password,
+	})
+}
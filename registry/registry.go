@@ -15,7 +15,7 @@ type Config struct {
 	Addrs     []string
 	Namespace string
 	Username  string
-	Password  string
+	Password  string `secret:"true"`
 	LogDir    string // nacos log dir
 	CacheDir  string // nacos cache dir
 }
@@ -0,0 +1,33 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+
+	"github.com/arklib/ark/errx"
+	"github.com/arklib/ark/metrics"
+)
+
+func MetricsMiddleware(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, req, resp any) error {
+		start := time.Now()
+		err := next(ctx, req, resp)
+
+		code := http.StatusOK
+		if err != nil {
+			code = errx.Wrap(err).Code()
+			metrics.ObserveError("rpc", code)
+		}
+
+		method := ""
+		if ri := rpcinfo.GetRPCInfo(ctx); ri != nil {
+			method = ri.Invocation().MethodName()
+		}
+		metrics.ObserveRPC(method, code, time.Since(start))
+		return err
+	}
+}
@@ -1,12 +1,15 @@
 package ark
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"reflect"
 	"strings"
 
 	kesvc "github.com/cloudwego/kitex/pkg/serviceinfo"
 
+	"github.com/arklib/ark/codegen"
 	"github.com/arklib/ark/errx"
 )
 
@@ -118,6 +121,9 @@ func (r *RPCRouter) setupRouter(rpcSrv *rpcServer, routes *RPCRoutes) error {
 		apiProxy.Use(route.ApiMiddlewares...)
 
 		// add kitex service method
+		if _, ok := rpcSrv.keSvc.Methods[route.FullPath]; ok {
+			return errx.Sprintf("rpc method already exists: %s", route.FullPath)
+		}
 		rpcSrv.keSvc.Methods[route.FullPath] = kesvc.NewMethodInfo(
 			apiProxy.RPCHandler,
 			apiProxy.NewInput,
@@ -125,6 +131,15 @@ func (r *RPCRouter) setupRouter(rpcSrv *rpcServer, routes *RPCRoutes) error {
 			false,
 		)
 
+		// add thrift idl method, "a/b" and "a_b" share the same name
+		if config.UseThriftIDL.Enable {
+			name := codegen.ThriftMethodName(route.FullPath)
+			if _, ok := rpcSrv.keSvc.Methods[name]; ok {
+				return errx.Sprintf("thrift method %s of %s already exists", name, route.FullPath)
+			}
+			rpcSrv.keSvc.Methods[name] = newThriftMethodInfo(apiProxy)
+		}
+
 		// add http route
 		if config.UseHttp && httpSrv != nil {
 			httpSrv.AddRoute(&HttpRoute{
@@ -146,3 +161,38 @@ func (r *RPCRouter) setupRouter(rpcSrv *rpcServer, routes *RPCRoutes) error {
 	}
 	return nil
 }
+
+// newThriftMethodInfo wrap input & output like thrift generated args & result,
+// so standard thrift clients can call the method
+func newThriftMethodInfo(proxy *ApiProxy) kesvc.MethodInfo {
+	argsType := reflect.StructOf([]reflect.StructField{{
+		Name: "Req",
+		Type: reflect.TypeOf(proxy.NewInput()),
+		Tag:  `frugal:"1,default"`,
+	}})
+	resultType := reflect.StructOf([]reflect.StructField{{
+		Name: "Success",
+		Type: reflect.TypeOf(proxy.NewOutput()),
+		Tag:  `frugal:"0,optional"`,
+	}})
+
+	return kesvc.NewMethodInfo(
+		func(ctx context.Context, handler, args, result any) error {
+			in := reflect.ValueOf(args).Elem().Field(0)
+			if in.IsNil() {
+				in.Set(reflect.ValueOf(proxy.NewInput()))
+			}
+
+			out := proxy.NewOutput()
+			err := proxy.RPCHandler(ctx, handler, in.Interface(), out)
+			if err != nil {
+				return err
+			}
+			reflect.ValueOf(result).Elem().Field(0).Set(reflect.ValueOf(out))
+			return nil
+		},
+		func() any { return reflect.New(argsType).Interface() },
+		func() any { return reflect.New(resultType).Interface() },
+		false,
+	)
+}
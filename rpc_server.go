@@ -39,12 +39,15 @@ type rpcServer struct {
 	keSrv  kesrv.Server
 	keSvc  *kesvc.ServiceInfo
 	Routes RPCRoutes
+	// closed by shutdown, kitex exit signal instead of SIGINT & SIGTERM
+	exitCh chan error
 }
 
 func newRPCServer(srv *Server) *rpcServer {
 	s := &rpcServer{
 		RPCRouter: newRPCRouter("", nil),
 		srv:       srv,
+		exitCh:    make(chan error),
 	}
 	return s
 }
@@ -75,6 +78,9 @@ func (s *rpcServer) init() error {
 		kesrv.WithServerBasicInfo(basicInfo),
 		kesrv.WithMuxTransport(),
 		kesrv.WithCompatibleMiddlewareForUnary(),
+		kesrv.WithExitWaitTime(srv.config.ShutdownTimeout),
+		// stopped only by Server.Shutdown, after the shutdown delay
+		kesrv.WithExitSignal(func() <-chan error { return s.exitCh }),
 	}
 
 	// codec
@@ -84,6 +90,11 @@ func (s *rpcServer) init() error {
 	}
 	options = append(options, kesrv.WithPayloadCodec(codec))
 
+	// metrics
+	if srv.config.UseMetrics {
+		options = append(options, kesrv.WithMiddleware(rpc.MetricsMiddleware))
+	}
+
 	// tracing
 	if config.UseTracing {
 		trace := tracing.NewServerSuite()
@@ -126,7 +137,7 @@ func (s *rpcServer) init() error {
 	return nil
 }
 
-func (s *rpcServer) run() error {
+func (s *rpcServer) setup() error {
 	if s.keSrv == nil {
 		if err := s.init(); err != nil {
 			return err
@@ -143,10 +154,24 @@ func (s *rpcServer) run() error {
 		if err = s.genCode(); err != nil {
 			return err
 		}
+		return s.genThriftIDL()
 	}
+	return nil
+}
+
+func (s *rpcServer) run() error {
 	return s.keSrv.Run()
 }
 
+func (s *rpcServer) shutdown() error {
+	if s.keSrv == nil {
+		return nil
+	}
+	close(s.exitCh)
+	// deregister service & wait in-flight calls
+	return s.keSrv.Stop()
+}
+
 func (s *rpcServer) UseRecovery() ApiMiddleware {
 	return func(p *ApiPayload) (err error) {
 		defer func() {
@@ -248,6 +273,34 @@ func (s *rpcServer) genCode() error {
 	return os.WriteFile(codeFile, code, 0666)
 }
 
+func (s *rpcServer) BuildThriftIDL() (string, error) {
+	pkg := codegen.NewThriftPackage()
+	for _, route := range s.Routes {
+		hInfo := route.Handler
+		err := pkg.AddMethod(route.FullPath, route.Title, hInfo.NewInput(), hInfo.NewOutput())
+		if err != nil {
+			return "", err
+		}
+	}
+	return pkg.Source(s.srv.config.RPCServer.Name), nil
+}
+
+func (s *rpcServer) genThriftIDL() error {
+	config := s.srv.config.RPCServer
+	if !config.UseThriftIDL.Enable || config.UseThriftIDL.Output == "" {
+		return nil
+	}
+
+	idl, err := s.BuildThriftIDL()
+	if err != nil {
+		return err
+	}
+
+	// output idl file
+	idlFile := fmt.Sprintf("%s/%s.thrift", config.UseThriftIDL.Output, config.Name)
+	return os.WriteFile(idlFile, []byte(idl), 0666)
+}
+
 func (s *rpcServer) GetRoutes() RPCRoutes {
 	return s.Routes
 }
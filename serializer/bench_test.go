@@ -0,0 +1,180 @@
+package serializer_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/arklib/ark/serializer"
+	"github.com/arklib/ark/serializer/internal/benchpb"
+)
+
+// go test ./serializer -run '^$' -bench . -benchmem
+
+type (
+	// UserProfile typical cache payload
+	UserProfile struct {
+		Id        int64    `json:"id" msgpack:"id" frugal:"1,default,i64"`
+		Name      string   `json:"name" msgpack:"name" frugal:"2,default,string"`
+		Email     string   `json:"email" msgpack:"email" frugal:"3,default,string"`
+		Avatar    string   `json:"avatar" msgpack:"avatar" frugal:"4,default,string"`
+		Tags      []string `json:"tags" msgpack:"tags" frugal:"5,default,list<string>"`
+		Score     float64  `json:"score" msgpack:"score" frugal:"6,default,double"`
+		CreatedAt int64    `json:"createdAt" msgpack:"createdAt" frugal:"7,default,i64"`
+	}
+
+	OrderItem struct {
+		Sku      string  `json:"sku" msgpack:"sku" frugal:"1,default,string"`
+		Quantity int32   `json:"quantity" msgpack:"quantity" frugal:"2,default,i32"`
+		Price    float64 `json:"price" msgpack:"price" frugal:"3,default,double"`
+	}
+
+	// OrderEvent typical queue payload
+	OrderEvent struct {
+		OrderId   string       `json:"orderId" msgpack:"orderId" frugal:"1,default,string"`
+		UserId    int64        `json:"userId" msgpack:"userId" frugal:"2,default,i64"`
+		Status    string       `json:"status" msgpack:"status" frugal:"3,default,string"`
+		Items     []*OrderItem `json:"items" msgpack:"items" frugal:"4,default,list<OrderItem>"`
+		Amount    float64      `json:"amount" msgpack:"amount" frugal:"5,default,double"`
+		CreatedAt int64        `json:"createdAt" msgpack:"createdAt" frugal:"6,default,i64"`
+	}
+
+	benchPayload struct {
+		name  string
+		value any
+		// new empty value for decode
+		new func() any
+	}
+
+	benchSerializer struct {
+		name       string
+		serializer serializer.Serializer
+		payloads   []*benchPayload
+	}
+)
+
+func newUserProfile() *UserProfile {
+	return &UserProfile{
+		Id:        10001,
+		Name:      "ark",
+		Email:     "ark@example.com",
+		Avatar:    "https://example.com/avatar/10001.png",
+		Tags:      []string{"vip", "beta", "cn"},
+		Score:     98.5,
+		CreatedAt: 1700000000,
+	}
+}
+
+func newOrderEvent() *OrderEvent {
+	event := &OrderEvent{
+		OrderId:   "20240101000010001",
+		UserId:    10001,
+		Status:    "paid",
+		CreatedAt: 1700000000,
+	}
+	for i := 0; i < 10; i++ {
+		item := &OrderItem{Sku: fmt.Sprintf("sku-%04d", i), Quantity: int32(i + 1), Price: 9.9}
+		event.Items = append(event.Items, item)
+		event.Amount += float64(item.Quantity) * item.Price
+	}
+	return event
+}
+
+// newProtoUserProfile same values as newUserProfile
+func newProtoUserProfile() *benchpb.UserProfile {
+	p := newUserProfile()
+	return &benchpb.UserProfile{
+		Id:        p.Id,
+		Name:      p.Name,
+		Email:     p.Email,
+		Avatar:    p.Avatar,
+		Tags:      p.Tags,
+		Score:     p.Score,
+		CreatedAt: p.CreatedAt,
+	}
+}
+
+// newProtoOrderEvent same values as newOrderEvent
+func newProtoOrderEvent() *benchpb.OrderEvent {
+	e := newOrderEvent()
+	event := &benchpb.OrderEvent{
+		OrderId:   e.OrderId,
+		UserId:    e.UserId,
+		Status:    e.Status,
+		Amount:    e.Amount,
+		CreatedAt: e.CreatedAt,
+	}
+	for _, item := range e.Items {
+		event.Items = append(event.Items, &benchpb.OrderItem{Sku: item.Sku, Quantity: item.Quantity, Price: item.Price})
+	}
+	return event
+}
+
+func benchSerializers(b *testing.B) []*benchSerializer {
+	payloads := []*benchPayload{
+		{name: "cache.UserProfile", value: newUserProfile(), new: func() any { return new(UserProfile) }},
+		{name: "queue.OrderEvent", value: newOrderEvent(), new: func() any { return new(OrderEvent) }},
+	}
+	protoPayloads := []*benchPayload{
+		{name: "cache.UserProfile", value: newProtoUserProfile(), new: func() any { return new(benchpb.UserProfile) }},
+		{name: "queue.OrderEvent", value: newProtoOrderEvent(), new: func() any { return new(benchpb.OrderEvent) }},
+	}
+
+	gzip, err := serializer.NewCompress(serializer.NewGoJson(), serializer.CompressGzip, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	zstd, err := serializer.NewCompress(serializer.NewGoJson(), serializer.CompressZstd, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return []*benchSerializer{
+		{"gojson", serializer.NewGoJson(), payloads},
+		{"sonic", serializer.NewSonic(), payloads},
+		{"frugal", serializer.NewFrugal(), payloads},
+		{"msgpack", serializer.NewMsgPack(), payloads},
+		{"protobuf", serializer.NewProtobuf(), protoPayloads},
+		{"gojson+gzip", gzip, payloads},
+		{"gojson+zstd", zstd, payloads},
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	for _, s := range benchSerializers(b) {
+		for _, payload := range s.payloads {
+			b.Run(s.name+"/"+payload.name, func(b *testing.B) {
+				data, err := s.serializer.Encode(payload.value)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					_, _ = s.serializer.Encode(payload.value)
+				}
+				b.ReportMetric(float64(len(data)), "bytes")
+			})
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	for _, s := range benchSerializers(b) {
+		for _, payload := range s.payloads {
+			b.Run(s.name+"/"+payload.name, func(b *testing.B) {
+				data, err := s.serializer.Encode(payload.value)
+				if err == nil {
+					err = s.serializer.Decode(data, payload.new())
+				}
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					_ = s.serializer.Decode(data, payload.new())
+				}
+			})
+		}
+	}
+}
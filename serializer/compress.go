@@ -0,0 +1,96 @@
+package serializer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
+)
+
+// compressed data: header byte + compressed stream (with its own magic)
+const (
+	compressHeaderGzip byte = 0xc1
+	compressHeaderZstd byte = 0xc2
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// Compress wrap a serializer, compress encoded data above threshold (bytes).
+// data below threshold or written without Compress are decoded by the wrapped serializer directly.
+type Compress struct {
+	Serializer
+	inner     Serializer
+	algo      string
+	threshold int
+}
+
+func (c *Compress) Encode(val any) ([]byte, error) {
+	data, err := c.inner.Encode(val)
+	if err != nil || len(data) < c.threshold {
+		return data, err
+	}
+
+	switch c.algo {
+	case CompressZstd:
+		buf := make([]byte, 1, len(data)/2+1)
+		buf[0] = compressHeaderZstd
+		return zstdEncoder.EncodeAll(data, buf), nil
+	default:
+		buf := bytes.NewBuffer(make([]byte, 0, len(data)/2+1))
+		buf.WriteByte(compressHeaderGzip)
+		w := gzip.NewWriter(buf)
+		if _, err = w.Write(data); err != nil {
+			return nil, err
+		}
+		if err = w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+func (c *Compress) Decode(data []byte, val any) (err error) {
+	switch {
+	case len(data) > 1 && data[0] == compressHeaderGzip && bytes.HasPrefix(data[1:], gzipMagic):
+		var r *gzip.Reader
+		if r, err = gzip.NewReader(bytes.NewReader(data[1:])); err != nil {
+			return err
+		}
+		defer r.Close()
+		if data, err = io.ReadAll(r); err != nil {
+			return err
+		}
+	case len(data) > 1 && data[0] == compressHeaderZstd && bytes.HasPrefix(data[1:], zstdMagic):
+		if data, err = zstdDecoder.DecodeAll(data[1:], nil); err != nil {
+			return err
+		}
+	}
+	return c.inner.Decode(data, val)
+}
+
+// NewCompress algo: gzip | zstd
+func NewCompress(inner Serializer, algo string, threshold int) (*Compress, error) {
+	if algo != CompressGzip && algo != CompressZstd {
+		return nil, fmt.Errorf("unknown compress algo: %s", algo)
+	}
+	if inner == nil {
+		return nil, errors.New("inner serializer cannot be nil")
+	}
+	return &Compress{inner: inner, algo: algo, threshold: threshold}, nil
+}
@@ -0,0 +1,116 @@
+package serializer
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// encrypted data: version byte + key id length byte + key id + nonce + sealed data
+const encryptVersion byte = 0x01
+
+var ErrEncryptData = errors.New("invalid encrypted data")
+
+type (
+	EncryptConfig struct {
+		// key id of encrypt, old keys are kept in Keys for decrypt
+		KeyId string `json:"keyId"`
+		// key id => base64 AES key (16, 24 or 32 bytes)
+		Keys map[string]string `json:"keys"`
+	}
+
+	// Encrypt wrap a serializer, encrypt encoded data with AES-GCM
+	Encrypt struct {
+		Serializer
+		inner Serializer
+		keyId string
+		aeads map[string]cipher.AEAD
+	}
+)
+
+func (e *Encrypt) Encode(val any) ([]byte, error) {
+	data, err := e.inner.Encode(val)
+	if err != nil {
+		return nil, err
+	}
+
+	aead := e.aeads[e.keyId]
+	n := 2 + len(e.keyId)
+	buf := make([]byte, n+aead.NonceSize(), n+aead.NonceSize()+len(data)+aead.Overhead())
+	buf[0] = encryptVersion
+	buf[1] = byte(len(e.keyId))
+	copy(buf[2:], e.keyId)
+
+	nonce := buf[n:]
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(buf, nonce, data, buf[:n]), nil
+}
+
+func (e *Encrypt) Decode(data []byte, val any) error {
+	if len(data) < 2 || data[0] != encryptVersion {
+		return ErrEncryptData
+	}
+
+	n := 2 + int(data[1])
+	if len(data) < n {
+		return ErrEncryptData
+	}
+
+	keyId := string(data[2:n])
+	aead, ok := e.aeads[keyId]
+	if !ok {
+		return fmt.Errorf("unknown encrypt key id: %s", keyId)
+	}
+	if len(data) < n+aead.NonceSize() {
+		return ErrEncryptData
+	}
+
+	nonce := data[n : n+aead.NonceSize()]
+	plain, err := aead.Open(nil, nonce, data[n+aead.NonceSize():], data[:n])
+	if err != nil {
+		return err
+	}
+	return e.inner.Decode(plain, val)
+}
+
+func NewEncrypt(inner Serializer, config EncryptConfig) (*Encrypt, error) {
+	if inner == nil {
+		return nil, errors.New("inner serializer cannot be nil")
+	}
+	if len(config.KeyId) > 255 {
+		return nil, errors.New("encrypt key id too long")
+	}
+	if _, ok := config.Keys[config.KeyId]; !ok {
+		return nil, fmt.Errorf("encrypt key id not found: %s", config.KeyId)
+	}
+
+	aeads := make(map[string]cipher.AEAD, len(config.Keys))
+	for keyId, value := range config.Keys {
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt key %s: %w", keyId, err)
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt key %s: %w", keyId, err)
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		aeads[keyId] = aead
+	}
+
+	return &Encrypt{
+		inner: inner,
+		keyId: config.KeyId,
+		aeads: aeads,
+	}, nil
+}
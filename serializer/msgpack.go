@@ -0,0 +1,19 @@
+package serializer
+
+import "github.com/vmihailenco/msgpack/v5"
+
+type MsgPack struct {
+	Serializer
+}
+
+func (MsgPack) Encode(val any) ([]byte, error) {
+	return msgpack.Marshal(val)
+}
+
+func (MsgPack) Decode(data []byte, val any) error {
+	return msgpack.Unmarshal(data, val)
+}
+
+func NewMsgPack() *MsgPack {
+	return new(MsgPack)
+}
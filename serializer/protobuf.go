@@ -0,0 +1,32 @@
+package serializer
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Protobuf for cache.Define & queue.Define of proto.Message data
+type Protobuf struct {
+	Serializer
+}
+
+func (Protobuf) Encode(val any) ([]byte, error) {
+	msg, ok := val.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protobuf: %T is not proto.Message", val)
+	}
+	return proto.Marshal(msg)
+}
+
+func (Protobuf) Decode(data []byte, val any) error {
+	msg, ok := val.(proto.Message)
+	if !ok {
+		return fmt.Errorf("protobuf: %T is not proto.Message", val)
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+func NewProtobuf() *Protobuf {
+	return new(Protobuf)
+}
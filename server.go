@@ -2,25 +2,35 @@ package ark
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gookit/goutil/dump"
 
 	"github.com/arklib/ark/config"
+	"github.com/arklib/ark/errx"
 	"github.com/arklib/ark/logger"
+	"github.com/arklib/ark/metrics"
 	"github.com/arklib/ark/registry"
 	"github.com/arklib/ark/task"
 	"github.com/arklib/ark/validator"
 )
 
 type ServerConfig struct {
-	Mode       string `default:"prod"`
-	Lang       string `default:"en"`
-	Logger     *logger.Config
-	Registry   *registry.Config
-	HttpServer struct {
+	Mode            string        `default:"prod"`
+	Lang            string        `default:"en"`
+	ShutdownTimeout time.Duration `default:"30s"`
+	// wait after marked not ready, before servers stop, for load balancers (like kubernetes endpoints) to catch up
+	ShutdownDelay time.Duration
+	UseMetrics    bool
+	Logger        *logger.Config
+	Registry      *registry.Config
+	HttpServer    struct {
 		Enable        bool
 		Name          string `default:"api"`
 		Addr          string `default:":8888"`
@@ -30,10 +40,26 @@ type ServerConfig struct {
 		UseRecovery   bool
 		UseAccessLog  bool
 		UseETag       bool
+		UseHealth     bool
 		UseFileRoutes []struct {
 			Path string
 			Root string
 		}
+		// openapi document
+		UseOpenAPI struct {
+			Enable        bool
+			Version       string `default:"1.0.0"`
+			Path          string `default:"openapi.json"`
+			UseSwaggerUI  bool
+			SwaggerUIPath string `default:"swagger"`
+			// export file (dev mode)
+			Output string
+		}
+		// generate typescript client code
+		UseTSCodeGen struct {
+			Enable bool
+			Output string
+		}
 	}
 	RPCServer struct {
 		Enable bool
@@ -49,6 +75,11 @@ type ServerConfig struct {
 			Enable bool
 			Output string
 		}
+		// thrift idl methods & export file (dev mode)
+		UseThriftIDL struct {
+			Enable bool
+			Output string
+		}
 	}
 	RPCClient struct {
 		Enable     bool
@@ -56,20 +87,40 @@ type ServerConfig struct {
 		Discover   []string
 		UseTracing bool
 	}
+	HttpClient struct {
+		Enable     bool
+		Timeout    time.Duration `default:"30s"`
+		MaxRetry   uint
+		RetryDelay time.Duration `default:"100ms"`
+		UseTracing bool
+	}
+	AdminServer struct {
+		Enable bool
+		Addr   string `default:"127.0.0.1:8890"`
+		Token  string `secret:"true"`
+	}
 }
 
+type LifecycleHook func(ctx context.Context) error
+
 type Server struct {
-	isRun      bool
-	dumper     *dump.Dumper
-	config     *ServerConfig
-	Mode       string
-	Config     *config.Config
-	Logger     *logger.Logger
-	Validator  *validator.Validator
-	HttpServer *httpServer
-	RPCClient  *rpcClient
-	RPCServer  *rpcServer
-	Task       *task.Task
+	isRun       bool
+	dumper      *dump.Dumper
+	config      *ServerConfig
+	onStart     []LifecycleHook
+	onStop      []LifecycleHook
+	stopOnce    sync.Once
+	stopCh      chan struct{}
+	Mode        string
+	Config      *config.Config
+	Logger      *logger.Logger
+	Validator   *validator.Validator
+	HttpServer  *httpServer
+	AdminServer *adminServer
+	HttpClient  *httpClient
+	RPCClient   *rpcClient
+	RPCServer   *rpcServer
+	Task        *task.Task
 }
 
 func MustNewServer(c *config.Config) *Server {
@@ -81,7 +132,10 @@ func MustNewServer(c *config.Config) *Server {
 }
 
 func NewServer(c *config.Config) (*Server, error) {
-	srv := &Server{Config: c}
+	srv := &Server{
+		Config: c,
+		stopCh: make(chan struct{}),
+	}
 	return srv, srv.init()
 }
 
@@ -109,6 +163,11 @@ func (srv *Server) init() (err error) {
 	// task
 	srv.Task = task.New()
 
+	// metrics
+	if sc.UseMetrics {
+		metrics.Enable()
+	}
+
 	// validator
 	srv.Validator = validator.New(sc.Lang)
 
@@ -126,6 +185,16 @@ func (srv *Server) init() (err error) {
 	if sc.RPCClient.Enable {
 		srv.RPCClient = newRPCClient(srv)
 	}
+
+	// http client
+	if sc.HttpClient.Enable {
+		srv.HttpClient = newHttpClient(srv)
+	}
+
+	// admin server
+	if sc.AdminServer.Enable {
+		srv.AdminServer = newAdminServer(srv)
+	}
 	return
 }
 
@@ -145,7 +214,10 @@ func (srv *Server) BindConfig(key string, value any) error {
 
 // Fetch http service
 func (srv *Server) Fetch(ctx context.Context, path string, in, out any) error {
-	return nil
+	if srv.HttpClient == nil {
+		return errx.New("http client is disabled")
+	}
+	return srv.HttpClient.Call(ctx, path, in, out)
 }
 
 // RPC thrift service
@@ -153,6 +225,16 @@ func (srv *Server) RPC(ctx context.Context, path string, in, out any) error {
 	return srv.RPCClient.Call(ctx, path, in, out)
 }
 
+// OnStart add hooks, run in order before servers start
+func (srv *Server) OnStart(hooks ...LifecycleHook) {
+	srv.onStart = append(srv.onStart, hooks...)
+}
+
+// OnStop add hooks, run in order after servers stop
+func (srv *Server) OnStop(hooks ...LifecycleHook) {
+	srv.onStop = append(srv.onStop, hooks...)
+}
+
 // Run http server & rpc server
 func (srv *Server) Run() {
 	if srv.isRun {
@@ -167,7 +249,35 @@ func (srv *Server) Run() {
 		}
 	}
 
-	errCh := make(chan error)
+	// init http client
+	if srv.HttpClient != nil {
+		if err := srv.HttpClient.init(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// on start hooks
+	for _, hook := range srv.onStart {
+		if err := hook(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// setup rpc server before http server, rpc routes may serve over http
+	if srv.RPCServer != nil {
+		if err := srv.RPCServer.setup(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// setup http server
+	if srv.HttpServer != nil {
+		if err := srv.HttpServer.setup(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	errCh := make(chan error, 3)
 	// start rpc server
 	if srv.RPCServer != nil {
 		go func() {
@@ -182,8 +292,78 @@ func (srv *Server) Run() {
 		}()
 	}
 
-	err := <-errCh
-	if err != nil {
+	// start admin server
+	if srv.AdminServer != nil {
+		go func() {
+			errCh <- srv.AdminServer.run()
+		}()
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			srv.Logger.Error(err)
+		}
+	case sig := <-sigCh:
+		srv.Logger.Infof("[ark] received signal: %s", sig)
+	case <-srv.stopCh:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), srv.config.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
 		srv.Logger.Error(err)
 	}
 }
+
+// Shutdown stop servers, drain in-flight calls, run stop hooks & flush logger
+func (srv *Server) Shutdown(ctx context.Context) (err error) {
+	srv.stopOnce.Do(func() {
+		close(srv.stopCh)
+		srv.Logger.Info("[ark] shutdown")
+
+		// mark not ready
+		if srv.HttpServer != nil {
+			srv.HttpServer.Health.Shutdown()
+		}
+
+		// drain delay
+		if delay := srv.config.ShutdownDelay; delay > 0 {
+			srv.Logger.Infof("[ark] shutdown delay: %s", delay)
+			select {
+			case <-ctx.Done():
+			case <-time.After(delay):
+			}
+		}
+
+		var errs []error
+		// stop rpc server
+		if srv.RPCServer != nil {
+			errs = append(errs, srv.RPCServer.shutdown())
+		}
+
+		// stop http server
+		if srv.HttpServer != nil {
+			errs = append(errs, srv.HttpServer.shutdown(ctx))
+		}
+
+		// stop admin server
+		if srv.AdminServer != nil {
+			errs = append(errs, srv.AdminServer.shutdown(ctx))
+		}
+
+		// on stop hooks
+		for _, hook := range srv.onStop {
+			errs = append(errs, hook(ctx))
+		}
+
+		// flush logger
+		errs = append(errs, srv.Logger.Sync())
+		err = errors.Join(errs...)
+	})
+	return
+}
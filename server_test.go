@@ -0,0 +1,113 @@
+package ark
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/arklib/ark/config"
+)
+
+func TestShutdownDelay(t *testing.T) {
+	httpAddr, rpcAddr := freeAddr(t), freeAddr(t)
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	content := fmt.Sprintf(`
+shutdownDelay: 500ms
+shutdownTimeout: 5s
+logger:
+  file: "%s"
+registry: {}
+httpServer:
+  enable: true
+  addr: "%s"
+  useHealth: true
+rpcServer:
+  enable: true
+  name: "svc"
+  addr: "%s"
+`, filepath.Join(t.TempDir(), "app.log"), httpAddr, rpcAddr)
+	if err := os.WriteFile(file, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	srv, err := NewServer(config.MustLoad(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		srv.Run()
+		close(done)
+	}()
+
+	// wait started
+	for i := 0; httpStatus(httpAddr, "/healthz") != http.StatusOK || !canDial(rpcAddr); i++ {
+		if i == 100 {
+			t.Fatal("server not started")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	start := time.Now()
+	// kitex must not stop on the signal by itself
+	if err = syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(250 * time.Millisecond)
+
+	// marked not ready, still serving
+	if status := httpStatus(httpAddr, "/readyz"); status != http.StatusServiceUnavailable {
+		t.Errorf("readyz during delay: want 503, got %d", status)
+	}
+	if status := httpStatus(httpAddr, "/healthz"); status != http.StatusOK {
+		t.Errorf("healthz during delay: want 200, got %d", status)
+	}
+	if !canDial(rpcAddr) {
+		t.Error("rpc server stopped during delay")
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server not stopped")
+	}
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Errorf("stopped before delay: %s", elapsed)
+	}
+	if canDial(httpAddr) || canDial(rpcAddr) {
+		t.Error("servers still serving after shutdown")
+	}
+}
+
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func httpStatus(addr, path string) int {
+	client := http.Client{Timeout: time.Second}
+	resp, err := client.Get("http://" + addr + path)
+	if err != nil {
+		return 0
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
+func canDial(addr string) bool {
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
@@ -3,6 +3,7 @@ package task
 import (
 	"fmt"
 	"log"
+	"sort"
 )
 
 type Handler = func() error
@@ -27,6 +28,25 @@ func (t *Task) Add(name string, handler Handler) {
 	t.handlers[name] = handler
 }
 
+// Names sorted task names
+func (t *Task) Names() []string {
+	names := make([]string, 0, len(t.handlers))
+	for name := range t.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Exec run one task and return its error
+func (t *Task) Exec(name string) error {
+	handler, ok := t.handlers[name]
+	if !ok {
+		return fmt.Errorf("task not found: %s", name)
+	}
+	return handler()
+}
+
 func (t *Task) Run(names ...string) {
 	if len(names) == 0 {
 		t.PrintList()
@@ -23,6 +23,14 @@ func GetFnName(handler any) string {
 	return fnNameRE.FindString(name)[1:]
 }
 
+// GetFnFullName like "pkg.(*Type).Method.func1"
+func GetFnFullName(fn any) string {
+	pointer := reflect.ValueOf(fn).Pointer()
+	name := runtime.FuncForPC(pointer).Name()
+	_, name = SplitSuffix(name, "/")
+	return name
+}
+
 func ForEachMapBySort[V any](in map[string]V, iteratee func(key string, value V)) {
 	keys := make([]string, 0, len(in))
 	for key := range in {